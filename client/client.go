@@ -0,0 +1,250 @@
+// Package client is a typed Go client of the decks API, so that programs using the API do not have to
+// write HTTP calls and response types of their own.
+//
+// Each method of DecksClient calls a route of the API, and returns its decoded response. Methods take a
+// context, which cancels the request and any wait between attempts. Calls that are idempotent (those using
+// GET or DELETE) are retried when the request fails or the API is temporarily unavailable, waiting longer
+// after each attempt. Other calls are made once, since retrying them could change a deck twice.
+//
+// Failed requests return an *Error holding the status and the error body of the API:
+//
+//	deck, err := decks.DrawCards(ctx, deckId, client.DrawCardsRequest{Count: &count})
+//	var apiError *client.Error
+//	if errors.As(err, &apiError) && apiError.Code == "insufficient_cards" {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default values of the options of a client
+const (
+	DefaultRetries    = 3                      // Number of retries of idempotent calls
+	DefaultMinBackoff = 100 * time.Millisecond // Wait before the first retry
+	DefaultMaxBackoff = 2 * time.Second        // Longest wait between two attempts
+)
+
+// Error returned when the API responds with an error body, or with an unexpected status
+type Error struct {
+	Status int // The HTTP status of the response
+	ErrorResponse
+}
+
+func (e *Error) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("decks API: %s (%d): %s, field: %s", e.Code, e.Status, e.Message, e.Field)
+	}
+	return fmt.Sprintf("decks API: %s (%d): %s", e.Code, e.Status, e.Message)
+}
+
+// Options of a client
+type Options struct {
+	HTTPClient *http.Client  // Client sending the requests, http.DefaultClient if nil
+	Actor      string        // Who applies the operations, recorded in the history of decks, if not empty
+	AdminToken string        // Bearer token of the admin routes, if not empty
+	Retries    *int          // Number of retries of idempotent calls, DefaultRetries if nil
+	MinBackoff time.Duration // Wait before the first retry, doubled before each next retry, DefaultMinBackoff if 0
+	MaxBackoff time.Duration // Longest wait between two attempts, DefaultMaxBackoff if 0
+}
+
+// Client of the decks API
+type DecksClient struct {
+	baseURL string
+	options Options
+	retries int
+}
+
+// Creates a client of the API served at the given base URL, such as "http://localhost:8080"
+func NewDecksClient(baseURL string) *DecksClient {
+	return NewDecksClientWithOptions(baseURL, Options{})
+}
+
+// Creates a client of the API served at the given base URL, with the given options
+func NewDecksClientWithOptions(baseURL string, options Options) *DecksClient {
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	if options.MinBackoff <= 0 {
+		options.MinBackoff = DefaultMinBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = DefaultMaxBackoff
+	}
+	retries := DefaultRetries
+	if options.Retries != nil {
+		retries = max(*options.Retries, 0)
+	}
+	return &DecksClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		options: options,
+		retries: retries,
+	}
+}
+
+// Returns a copy of the client recording the given actor in the history of the decks it changes
+func (dc *DecksClient) WithActor(actor string) *DecksClient {
+	withActor := *dc
+	withActor.options.Actor = actor
+	return &withActor
+}
+
+// A request to the API
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        any       // Encoded as the JSON request body, if not nil
+	rawBody     io.Reader // Sent as the request body, if not nil
+	contentType string    // The content type of the raw body
+	status      int       // The status of successful responses
+	deletes     bool      // If the request deletes what it targets, so that not finding it on a retry is a success
+}
+
+// Sends a request and decodes the JSON response body into result, if not nil
+func (dc *DecksClient) call(ctx context.Context, r request, result any) error {
+	response, err := dc.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("decks API: invalid response body: %w", err)
+	}
+	return nil
+}
+
+// Sends a request and returns its decoded JSON response body
+func callFor[T any](ctx context.Context, dc *DecksClient, r request) (*T, error) {
+	var result T
+	if err := dc.call(ctx, r, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Sends a request, retrying idempotent requests, and returns the successful response. The caller closes its body.
+func (dc *DecksClient) send(ctx context.Context, r request) (*http.Response, error) {
+	var body []byte
+	if r.body != nil {
+		var err error
+		if body, err = json.Marshal(r.body); err != nil {
+			return nil, err
+		}
+	}
+
+	target := dc.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+	if _, err := url.Parse(target); err != nil {
+		return nil, err
+	}
+
+	retries := 0
+	if (r.method == http.MethodGet || r.method == http.MethodDelete) && r.rawBody == nil {
+		retries = dc.retries
+	}
+
+	backoff := dc.options.MinBackoff
+	for attempt := 0; ; attempt++ {
+		response, err := dc.sendOnce(ctx, r, target, body)
+		if err == nil && response.StatusCode == r.status {
+			return response, nil
+		}
+		// The response to an earlier attempt may have been lost after the deletion was done
+		if err == nil && attempt > 0 && r.deletes && response.StatusCode == http.StatusNotFound {
+			return response, nil
+		}
+		if err == nil {
+			err = errorOf(response)
+		}
+
+		if attempt == retries || !retryable(ctx, response, err) {
+			return nil, err
+		}
+
+		// Full jitter, so that clients failing at the same time do not retry at the same time
+		timer := time.NewTimer(time.Duration(rand.Int63n(int64(backoff)) + 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, dc.options.MaxBackoff)
+	}
+}
+
+// Sends a request once
+func (dc *DecksClient) sendOnce(ctx context.Context, r request, target string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	switch {
+	case r.rawBody != nil:
+		reader = r.rawBody
+	case body != nil:
+		reader = bytes.NewReader(body)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, r.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case r.rawBody != nil:
+		httpRequest.Header.Set("Content-Type", r.contentType)
+	case body != nil:
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+	httpRequest.Header.Set("Accept", "application/json")
+	if dc.options.Actor != "" {
+		httpRequest.Header.Set("X-Actor", dc.options.Actor)
+	}
+	if dc.options.AdminToken != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+dc.options.AdminToken)
+	}
+
+	return dc.options.HTTPClient.Do(httpRequest)
+}
+
+// Returns the error of an unsuccessful response, and closes its body
+func errorOf(response *http.Response) error {
+	defer response.Body.Close()
+
+	apiError := &Error{Status: response.StatusCode}
+	if err := json.NewDecoder(response.Body).Decode(&apiError.ErrorResponse); err != nil || apiError.Code == "" {
+		apiError.ErrorResponse = ErrorResponse{
+			Code:    "unexpected_status",
+			Message: fmt.Sprintf("unexpected status: %s", response.Status),
+		}
+	}
+	return apiError
+}
+
+// Tells if a failed attempt may succeed when it is retried: requests that could not be sent or got no
+// response, and responses telling that the API is overloaded or failed unexpectedly
+func retryable(ctx context.Context, response *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiError *Error
+	if errors.As(err, &apiError) {
+		return apiError.Status == http.StatusTooManyRequests || apiError.Status >= http.StatusInternalServerError
+	}
+	return response == nil
+}
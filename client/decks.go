@@ -0,0 +1,249 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filters and page of listed decks. Filters that are not set match all decks.
+type ListDecksOptions struct {
+	Limit        int               // The maximum number of decks in the page, the default of the API if 0
+	Cursor       string            // The next cursor returned with the previous page, the first page if empty
+	CreatedAfter time.Time         // Only decks created after this time are listed, if not zero
+	Shuffled     *bool             // Only shuffled or unshuffled decks are listed, if not nil
+	MinRemaining *int              // Only decks with at least this number of remaining cards are listed, if not nil
+	MaxRemaining *int              // Only decks with at most this number of remaining cards are listed, if not nil
+	Type         string            // Only decks of this type are listed, if not empty
+	Labels       map[string]string // Only decks with all these labels are listed
+}
+
+// Options for cloning a deck
+type CloneOptions struct {
+	Reshuffle bool   // If the remaining cards of the clone are shuffled
+	Seed      *int64 // Seed of the reshuffle, random if nil
+}
+
+// Options for applying an operation to the remaining cards of a deck
+type OperationOptions struct {
+	Operation string // The operation: "cut", "faro_out", "faro_in", "riffle" or "overhand"
+	Position  *int   // With the cut operation, the number of cards moved to the bottom, random if nil
+	Times     int    // The number of times the operation is applied, once if 0
+}
+
+// Creates a deck
+func (dc *DecksClient) CreateDeck(ctx context.Context, options CreateDeckRequest) (*DeckStateResponse, error) {
+	return callFor[DeckStateResponse](ctx, dc, request{method: http.MethodPost, path: "/v2/decks", body: options, status: http.StatusCreated})
+}
+
+// Returns the state of a deck, with its remaining cards unless they are hidden
+func (dc *DecksClient) DescribeDeck(ctx context.Context, deckId string) (*DeckStateResponse, error) {
+	return callFor[DeckStateResponse](ctx, dc, get("/v2/decks/"+url.PathEscape(deckId), nil))
+}
+
+// Opens a deck, with its remaining cards
+func (dc *DecksClient) OpenDeck(ctx context.Context, deckId string) (*OpenDeckResponse, error) {
+	return callFor[OpenDeckResponse](ctx, dc, get(deckPath(deckId, "open"), nil))
+}
+
+// Draws either a number of cards or the given cards
+func (dc *DecksClient) DrawCards(ctx context.Context, deckId string, options DrawCardsRequest) (*DeckStateResponse, error) {
+	return callFor[DeckStateResponse](ctx, dc, post("/v2/decks/"+url.PathEscape(deckId)+"/draw", nil, options))
+}
+
+// Returns drawn cards to a deck, all of them if no cards are given
+func (dc *DecksClient) ReturnCards(ctx context.Context, deckId string, options ReturnCardsRequest) (*DeckStateResponse, error) {
+	return callFor[DeckStateResponse](ctx, dc, post("/v2/decks/"+url.PathEscape(deckId)+"/return", nil, options))
+}
+
+// Shuffles the remaining cards of a deck
+func (dc *DecksClient) ShuffleDeck(ctx context.Context, deckId string, options ShuffleDeckRequest) (*DeckStateResponse, error) {
+	return callFor[DeckStateResponse](ctx, dc, post("/v2/decks/"+url.PathEscape(deckId)+"/shuffle", nil, options))
+}
+
+// Peeks at cards of a deck without drawing them, from the top unless the position is "bottom"
+func (dc *DecksClient) PeekCards(ctx context.Context, deckId string, count int, position string) (*PeekCardsResponse, error) {
+	query := url.Values{"count": {strconv.Itoa(count)}}
+	setIfNotEmpty(query, "position", position)
+	return callFor[PeekCardsResponse](ctx, dc, get(deckPath(deckId, "peek"), query))
+}
+
+// Lists the decks matching the given filters, a page at a time
+func (dc *DecksClient) ListDecks(ctx context.Context, options ListDecksOptions) (*ListDecksResponse, error) {
+	query := url.Values{}
+	if options.Limit != 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+	setIfNotEmpty(query, "cursor", options.Cursor)
+	if !options.CreatedAfter.IsZero() {
+		query.Set("created_after", options.CreatedAfter.Format(time.RFC3339Nano))
+	}
+	if options.Shuffled != nil {
+		query.Set("shuffled", strconv.FormatBool(*options.Shuffled))
+	}
+	if options.MinRemaining != nil {
+		query.Set("min_remaining", strconv.Itoa(*options.MinRemaining))
+	}
+	if options.MaxRemaining != nil {
+		query.Set("max_remaining", strconv.Itoa(*options.MaxRemaining))
+	}
+	setIfNotEmpty(query, "type", options.Type)
+	var labels []string
+	for key, value := range options.Labels {
+		labels = append(labels, key+":"+value)
+	}
+	sort.Strings(labels)
+	setIfNotEmpty(query, "labels", strings.Join(labels, ","))
+
+	return callFor[ListDecksResponse](ctx, dc, get("/decks", query))
+}
+
+// Lists the deck types
+func (dc *DecksClient) ListDeckTypes(ctx context.Context) (*DeckTypesResponse, error) {
+	return callFor[DeckTypesResponse](ctx, dc, get("/deck-types", nil))
+}
+
+// Deletes a deck. When the deletion is retried, a deck that is no longer found was deleted by an earlier attempt,
+// and the deletion succeeds.
+func (dc *DecksClient) DeleteDeck(ctx context.Context, deckId string) error {
+	r := request{method: http.MethodDelete, path: "/deck/" + url.PathEscape(deckId), status: http.StatusNoContent, deletes: true}
+	return dc.call(ctx, r, nil)
+}
+
+// Closes a deck, so it can no longer be changed
+func (dc *DecksClient) CloseDeck(ctx context.Context, deckId string) (*CloseDeckResponse, error) {
+	return callFor[CloseDeckResponse](ctx, dc, post(deckPath(deckId, "close"), nil, nil))
+}
+
+// Creates a new deck in the exact state of a deck
+func (dc *DecksClient) CloneDeck(ctx context.Context, deckId string, options CloneOptions) (*CloneDeckResponse, error) {
+	query := url.Values{}
+	if options.Reshuffle {
+		query.Set("reshuffle", "true")
+	}
+	if options.Seed != nil {
+		query.Set("seed", strconv.FormatInt(*options.Seed, 10))
+	}
+	return callFor[CloneDeckResponse](ctx, dc, post(deckPath(deckId, "clone"), query, nil))
+}
+
+// Applies a cut or shuffle operation to the remaining cards of a deck
+func (dc *DecksClient) ApplyOperation(ctx context.Context, deckId string, options OperationOptions) (*DeckOperationResponse, error) {
+	query := url.Values{"operation": {options.Operation}}
+	if options.Position != nil {
+		query.Set("position", strconv.Itoa(*options.Position))
+	}
+	if options.Times != 0 {
+		query.Set("times", strconv.Itoa(options.Times))
+	}
+	return callFor[DeckOperationResponse](ctx, dc, post(deckPath(deckId, "operations"), query, nil))
+}
+
+// Lists the history of a deck
+func (dc *DecksClient) History(ctx context.Context, deckId string) (*HistoryResponse, error) {
+	return callFor[HistoryResponse](ctx, dc, get(deckPath(deckId, "history"), nil))
+}
+
+// Undoes the given number of last operations applied to a deck
+func (dc *DecksClient) Undo(ctx context.Context, deckId string, count int) (*UndoResponse, error) {
+	return callFor[UndoResponse](ctx, dc, post(deckPath(deckId, "undo"), url.Values{"count": {strconv.Itoa(count)}}, nil))
+}
+
+// Verifies the provably fair shuffle of a deck
+func (dc *DecksClient) VerifyDeck(ctx context.Context, deckId string) (*VerifyDeckResponse, error) {
+	return callFor[VerifyDeckResponse](ctx, dc, get(deckPath(deckId, "verify"), nil))
+}
+
+// Burns cards, then deals the given number of cards to each player, round-robin
+func (dc *DecksClient) Deal(ctx context.Context, deckId string, players []string, count int, burn int) (*DealResponse, error) {
+	query := url.Values{
+		"players": {strings.Join(players, ",")},
+		"count":   {strconv.Itoa(count)},
+		"burn":    {strconv.Itoa(burn)},
+	}
+	return callFor[DealResponse](ctx, dc, post(deckPath(deckId, "deal"), query, nil))
+}
+
+// Adds drawn cards to a pile: the given cards, or else the given number of cards drawn from the deck
+func (dc *DecksClient) AddToPile(ctx context.Context, deckId string, pile string, draw int, cards []string) (*PileResponse, error) {
+	query := url.Values{}
+	if cards != nil {
+		query.Set("cards", strings.Join(cards, ","))
+	} else {
+		query.Set("draw", strconv.Itoa(draw))
+	}
+	return callFor[PileResponse](ctx, dc, post(pilePath(deckId, pile, "add"), query, nil))
+}
+
+// Lists the cards of a pile
+func (dc *DecksClient) ListPile(ctx context.Context, deckId string, pile string) (*PileResponse, error) {
+	return callFor[PileResponse](ctx, dc, get(pilePath(deckId, pile, "list"), nil))
+}
+
+// Draws the given number of cards from the top of a pile
+func (dc *DecksClient) DrawFromPile(ctx context.Context, deckId string, pile string, draw int) (*DrawCardsResponse, error) {
+	return callFor[DrawCardsResponse](ctx, dc, post(pilePath(deckId, pile, "draw"), url.Values{"draw": {strconv.Itoa(draw)}}, nil))
+}
+
+// Shuffles the cards of a pile, with the given seed unless it is nil
+func (dc *DecksClient) ShufflePile(ctx context.Context, deckId string, pile string, seed *int64) (*PileResponse, error) {
+	query := url.Values{}
+	if seed != nil {
+		query.Set("seed", strconv.FormatInt(*seed, 10))
+	}
+	return callFor[PileResponse](ctx, dc, post(pilePath(deckId, pile, "shuffle"), query, nil))
+}
+
+// Returns the cards of a pile to the deck, at the top unless another position is given
+func (dc *DecksClient) ReturnPile(ctx context.Context, deckId string, pile string, position string) (*ReturnCardsResponse, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "position", position)
+	return callFor[ReturnCardsResponse](ctx, dc, post(pilePath(deckId, pile, "return"), query, nil))
+}
+
+// Exports all decks as a snapshot file in the given format, "jsonl" or "gzip". The caller closes the snapshot.
+// Requires the admin token.
+func (dc *DecksClient) ExportDecks(ctx context.Context, format string) (io.ReadCloser, error) {
+	response, err := dc.send(ctx, get("/admin/export", url.Values{"format": {format}}))
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// Imports the decks of a snapshot file, in either format. Requires the admin token.
+func (dc *DecksClient) ImportDecks(ctx context.Context, snapshot io.Reader) (*ImportDecksResponse, error) {
+	r := request{method: http.MethodPost, path: "/admin/import", rawBody: snapshot, contentType: "application/octet-stream", status: http.StatusOK}
+	return callFor[ImportDecksResponse](ctx, dc, r)
+}
+
+// Returns a GET request
+func get(path string, query url.Values) request {
+	return request{method: http.MethodGet, path: path, query: query, status: http.StatusOK}
+}
+
+// Returns a POST request, with a JSON request body unless body is nil
+func post(path string, query url.Values, body any) request {
+	return request{method: http.MethodPost, path: path, query: query, body: body, status: http.StatusOK}
+}
+
+// Returns the path of a route of a deck of version 1
+func deckPath(deckId string, route string) string {
+	return "/deck/" + url.PathEscape(deckId) + "/" + route
+}
+
+// Returns the path of a route of a pile of a deck
+func pilePath(deckId string, pile string, route string) string {
+	return deckPath(deckId, "pile/"+url.PathEscape(pile)+"/"+route)
+}
+
+func setIfNotEmpty(query url.Values, key string, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import "github.com/rnkjnk/decks-api/internal/models/dto"
+
+// The request and response bodies of the API are those of the API itself. They are aliased here, since
+// programs outside of this module cannot import its internal packages.
+
+// Request bodies
+type (
+	CreateDeckRequest  = dto.CreateDeckRequest
+	DrawCardsRequest   = dto.DrawCardsRequest
+	ReturnCardsRequest = dto.ReturnCardsRequest
+	ShuffleDeckRequest = dto.ShuffleDeckRequest
+)
+
+// Response bodies
+type (
+	CloneDeckResponse     = dto.CloneDeckResponse
+	CloseDeckResponse     = dto.CloseDeckResponse
+	CreateDeckResponse    = dto.CreateDeckResponse
+	DealResponse          = dto.DealResponse
+	DeckOperationResponse = dto.DeckOperationResponse
+	DeckStateResponse     = dto.DeckStateResponse
+	DeckTypesResponse     = dto.DeckTypesResponse
+	DrawCardsResponse     = dto.DrawCardsResponse
+	ErrorResponse         = dto.ErrorResponse
+	HistoryResponse       = dto.HistoryResponse
+	ImportDecksResponse   = dto.ImportDecksResponse
+	ListDecksResponse     = dto.ListDecksResponse
+	OpenDeckResponse      = dto.OpenDeckResponse
+	PeekCardsResponse     = dto.PeekCardsResponse
+	PileResponse          = dto.PileResponse
+	ReturnCardsResponse   = dto.ReturnCardsResponse
+	ShuffleDeckResponse   = dto.ShuffleDeckResponse
+	UndoResponse          = dto.UndoResponse
+	VerifyDeckResponse    = dto.VerifyDeckResponse
+)
+
+// Objects of the response bodies
+type (
+	CardDto        = dto.CardDto
+	DeckEventDto   = dto.DeckEventDto
+	DeckSummaryDto = dto.DeckSummaryDto
+	DeckTypeDto    = dto.DeckTypeDto
+	FairnessDto    = dto.FairnessDto
+	HandDto        = dto.HandDto
+)
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/rnkjnk/decks-api/internal/api"
 	"github.com/rnkjnk/decks-api/internal/services"
 	"github.com/rnkjnk/decks-api/internal/utils"
 )
 
+// Time given to in-flight requests to complete on shutdown
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	// Initialize Gin router
 	router := gin.Default()
@@ -15,17 +27,46 @@ func main() {
 	config := utils.GetConfigsFromYaml("config.yaml")
 
 	// Initialize repository
-	store := services.NewDecksInMemoryStore()
+	store, err := services.NewDecksStore(config.Store)
+	if err != nil {
+		panic("Failed to initialize decks repository: " + err.Error())
+	}
 
 	// Inject dependencies into decks service
 	service := services.NewDecksService(config.Decks, store)
 
 	// Inject dependencies into handlers
-	handlers := api.NewHandlers(service)
+	handlers := api.NewHandlersWithAdminToken(service, config.Api.AdminToken)
 
 	// Set up routes
 	handlers.SetupRoutes(router)
 
 	// Start the server
-	router.Run(":" + config.Api.ServerPort)
+	server := &http.Server{
+		Addr:    ":" + config.Api.ServerPort,
+		Handler: router,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for an interrupt, then shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+
+	// Stop background work of the repository, and release its resources
+	if closer, ok := store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Failed to close decks repository: %v", err)
+		}
+	}
 }
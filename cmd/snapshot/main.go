@@ -0,0 +1,105 @@
+// Command snapshot exports all decks of the repository configured in config.yaml to a snapshot file,
+// or imports the decks of a snapshot file into it.
+//
+// Usage:
+//
+//	snapshot export [-config config.yaml] [-format jsonl|gzip] FILE
+//	snapshot import [-config config.yaml] FILE
+//
+// FILE is "-" for the standard output or input. The API should be stopped while the sqlite or journal
+// repository is exported or imported, so that no deck changes in the meantime.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+	"github.com/rnkjnk/decks-api/internal/services"
+	"github.com/rnkjnk/decks-api/internal/utils"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	configPath := flags.String("config", "config.yaml", "path to the configuration file")
+	format := flags.String("format", services.SnapshotFormatJsonl, "format of the exported snapshot: jsonl or gzip")
+	flags.Parse(os.Args[2:])
+	if flags.NArg() != 1 {
+		usage()
+	}
+	path := flags.Arg(0)
+
+	// Load configuration
+	config := utils.GetConfigsFromYaml(*configPath)
+
+	// Initialize repository
+	store, err := services.NewDecksStore(config.Store)
+	if err != nil {
+		log.Fatalf("Failed to initialize decks repository: %v", err)
+	}
+
+	var count int
+	switch command {
+	case "export":
+		count, err = export(store, path, *format)
+	case "import":
+		count, err = importFile(store, config.Decks, path)
+	default:
+		usage()
+	}
+
+	// Release the repository, which writes pending changes of some repositories
+	if closer, ok := store.(io.Closer); ok {
+		if closeErr := closer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to %s decks after %d deck(s): %v", command, count, err)
+	}
+	log.Printf("%sed %d deck(s)", command, count)
+}
+
+// Exports all decks to a file
+func export(store services.DecksStorer, path string, format string) (int, error) {
+	if path == "-" {
+		return services.ExportSnapshot(store, os.Stdout, format)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	count, err := services.ExportSnapshot(store, file, format)
+	if closeErr := file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return count, err
+}
+
+// Imports the decks of a file, checked against the deck types of the configuration
+func importFile(store services.DecksStorer, config configs.DecksConfig, path string) (int, error) {
+	if path == "-" {
+		return services.ImportSnapshot(store, config, os.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return services.ImportSnapshot(store, config, file)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: snapshot export [-config config.yaml] [-format jsonl|gzip] FILE")
+	fmt.Fprintln(os.Stderr, "       snapshot import [-config config.yaml] FILE")
+	os.Exit(2)
+}
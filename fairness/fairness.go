@@ -0,0 +1,149 @@
+// Package fairness implements the provably fair shuffle of the decks API, so that players can verify
+// that the order of a deck was fixed before they supplied their seed, and was not chosen by the server.
+//
+// A provably fair deck is shuffled as follows:
+//  1. The server picks a secret server seed of 32 random bytes, and publishes its commitment,
+//     the SHA-256 hash of the server seed, before the player supplies a client seed.
+//  2. A stream of random bytes is made of the blocks HMAC-SHA256(server seed, client seed + ":" + k),
+//     for k = 0, 1, 2, ... written in decimal. Each block is split into four big-endian 64-bit numbers,
+//     which are used in order.
+//  3. A random number in [0, m) is taken from the next number x of the stream, rejecting and skipping x
+//     if x >= 2^64 - (2^64 mod m), and taking x mod m otherwise, so that all results are equally likely.
+//  4. The cards, in the order they would have without shuffling, are shuffled by Fisher-Yates:
+//     for i from n-1 down to 1, the card at i is swapped with the card at a random position in [0, i+1).
+//
+// The server seed is revealed once the deck is exhausted or closed. Anyone can then check the commitment,
+// and recompute the order of the deck with Verify.
+package fairness
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+)
+
+// Number of random bytes of a server seed
+const SeedSize = 32
+
+var (
+	ErrInvalidSeed        = errors.New("invalid server seed")                   // The server seed is not hex encoded
+	ErrCommitmentMismatch = errors.New("server seed does not match commitment") // The server seed is not the one committed to
+	ErrOrderMismatch      = errors.New("order of cards does not match seeds")   // The cards were not shuffled with the seeds
+)
+
+// Returns a new secret server seed, hex encoded
+func NewServerSeed() (string, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return "", fmt.Errorf("error generating server seed: %w", err)
+	}
+	return hex.EncodeToString(seed), nil
+}
+
+// Returns the commitment of a hex encoded server seed, the hex encoded SHA-256 hash of its bytes
+func Commit(serverSeed string) (string, error) {
+	key, err := hex.DecodeString(serverSeed)
+	if err != nil {
+		return "", ErrInvalidSeed
+	}
+	hash := sha256.Sum256(key)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Returns the permutation of n cards shuffled with the given seeds: the card at position i of the shuffled deck
+// is the card at position result[i] of the unshuffled deck
+func Permutation(serverSeed string, clientSeed string, n int) ([]int, error) {
+	key, err := hex.DecodeString(serverSeed)
+	if err != nil {
+		return nil, ErrInvalidSeed
+	}
+	stream := &randomStream{mac: hmac.New(sha256.New, key), clientSeed: clientSeed}
+
+	result := make([]int, n)
+	for i := range result {
+		result[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := stream.intn(i + 1)
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, nil
+}
+
+// Returns the cards shuffled with the given seeds
+func Shuffle(serverSeed string, clientSeed string, cards []string) ([]string, error) {
+	permutation, err := Permutation(serverSeed, clientSeed, len(cards))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(cards))
+	for i, j := range permutation {
+		result[i] = cards[j]
+	}
+	return result, nil
+}
+
+// Verifies that a server seed matches its commitment, and that the cards of a deck, in the order they would have
+// without shuffling, were shuffled to the given order with the server and client seeds
+func Verify(serverSeed string, commitment string, clientSeed string, unshuffled []string, shuffled []string) error {
+	committed, err := Commit(serverSeed)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(committed), []byte(commitment)) {
+		return ErrCommitmentMismatch
+	}
+	expected, err := Shuffle(serverSeed, clientSeed, unshuffled)
+	if err != nil {
+		return err
+	}
+	if len(expected) != len(shuffled) {
+		return ErrOrderMismatch
+	}
+	for i := range expected {
+		if expected[i] != shuffled[i] {
+			return ErrOrderMismatch
+		}
+	}
+	return nil
+}
+
+// Stream of random numbers derived from the seeds
+type randomStream struct {
+	mac        hash.Hash
+	clientSeed string
+	block      []byte // Unused bytes of the current block
+	counter    int    // Number of the next block
+}
+
+// Returns the next number of the stream
+func (s *randomStream) uint64() uint64 {
+	if len(s.block) == 0 {
+		s.mac.Reset()
+		s.mac.Write([]byte(s.clientSeed + ":" + strconv.Itoa(s.counter)))
+		s.block = s.mac.Sum(nil)
+		s.counter++
+	}
+	x := binary.BigEndian.Uint64(s.block)
+	s.block = s.block[8:]
+	return x
+}
+
+// Returns a random number in [0, m), without modulo bias
+func (s *randomStream) intn(m int) int {
+	bound := uint64(m)
+	// 2^64 mod m, computed without overflowing
+	excess := -bound % bound
+	for {
+		x := s.uint64()
+		if excess == 0 || x < -excess {
+			return int(x % bound)
+		}
+	}
+}
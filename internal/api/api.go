@@ -1,30 +1,77 @@
 package api
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
 	"github.com/rnkjnk/decks-api/internal/services"
 )
 
+// Header of requests identifying who applies the operations, recorded in the history of decks
+const ActorHeader = "X-Actor"
+
 type handlers struct {
-	service services.DecksServicer
+	service    services.DecksServicer
+	adminToken string // Bearer token required by the admin routes, which are disabled if it is empty
 }
 
 func NewHandlers(service services.DecksServicer) *handlers {
+	return NewHandlersWithAdminToken(service, "")
+}
+
+// Creates handlers with admin routes enabled for requests with the given bearer token
+func NewHandlersWithAdminToken(service services.DecksServicer, adminToken string) *handlers {
 	return &handlers{
-		service: service,
+		service:    service,
+		adminToken: adminToken,
 	}
 }
 
 func (h *handlers) SetupRoutes(router *gin.Engine) {
 	// Define routes and attach handler functions
 	router.POST("/deck", h.createDeck)
+	router.GET("/decks", h.listDecks)
+	router.GET("/deck-types", h.listDeckTypes)
+	router.DELETE("/deck/:id", h.deleteDeck)
+	router.POST("/deck/:id/close", h.closeDeck)
+	router.POST("/deck/:id/clone", h.cloneDeck)
 	router.GET("/deck/:id/open", h.openDeck)
 	router.POST("/deck/:id/draw-cards", h.drawCards)
+	router.GET("/deck/:id/peek", h.peekCards)
+	router.POST("/deck/:id/return", h.returnCards)
+	router.POST("/deck/:id/shuffle", h.shuffleDeck)
+	router.POST("/deck/:id/operations", h.applyOperation)
+	router.GET("/deck/:id/history", h.history)
+	router.POST("/deck/:id/undo", h.undo)
+	router.GET("/deck/:id/verify", h.verifyDeck)
+	router.POST("/deck/:id/deal", h.deal)
+	router.POST("/deck/:id/pile/:name/add", h.addToPile)
+	router.GET("/deck/:id/pile/:name/list", h.listPile)
+	router.POST("/deck/:id/pile/:name/draw", h.drawFromPile)
+	router.POST("/deck/:id/pile/:name/shuffle", h.shufflePile)
+	router.POST("/deck/:id/pile/:name/return", h.returnPile)
+
+	router.GET("/openapi.json", h.openAPI)
+	router.GET("/docs", h.docs)
+	router.GET("/docs/swagger-ui.css", h.docsStyle)
+	router.GET("/docs/swagger-ui-bundle.js", h.docsScript)
+
+	v2 := router.Group("/v2")
+	v2.POST("/decks", h.createDeckV2)
+	v2.GET("/decks/:id", h.getDeckV2)
+	v2.POST("/decks/:id/draw", h.drawCardsV2)
+	v2.POST("/decks/:id/return", h.returnCardsV2)
+	v2.POST("/decks/:id/shuffle", h.shuffleDeckV2)
+
+	admin := router.Group("/admin", h.requireAdmin)
+	admin.GET("/export", h.exportDecks)
+	admin.POST("/import", h.importDecks)
 }
 
 // Creates a deck
@@ -33,17 +80,157 @@ func (h *handlers) createDeck(c *gin.Context) {
 
 	cards, err := stringToCardCodeSlice(c.Query("cards"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondWithError(c, err)
 		return
 	}
 
-	deck, err := h.service.CreateDeck(shuffle, cards)
+	deckCount, err := stringToCount("deck_count", c.DefaultQuery("deck_count", "1"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondWithError(c, err)
+		return
+	}
+	if deckCount < 1 || deckCount > services.MaxDeckCount {
+		respondWithError(c, requestError("deck_count", "deck count must be between 1 and %d, got: %d", services.MaxDeckCount, deckCount))
+		return
+	}
+
+	seed, err := stringToSeed(c.Query("seed"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	labels, err := stringToLabels(c.Query("labels"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	deck, err := h.serviceFor(c).CreateDeck(services.CreateDeckOptions{
+		Shuffle:    shuffle,
+		Cards:      cards,
+		DeckCount:  deckCount,
+		Type:       c.Query("type"),
+		Seed:       seed,
+		Fair:       stringToBoolDefault(c.DefaultQuery("fair", "false"), false),
+		ClientSeed: c.Query("client_seed"),
+		Hidden:     stringToBoolDefault(c.DefaultQuery("hidden", "false"), false),
+		Labels:     labels,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// Lists the decks matching the filter parameters, a page at a time
+func (h *handlers) listDecks(c *gin.Context) {
+	limit, err := stringToCount("limit", c.DefaultQuery("limit", "0"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	filter := services.DecksFilter{Type: c.Query("type")}
+
+	if s := c.Query("created_after"); s != "" {
+		filter.CreatedAfter, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			respondWithError(c, invalidParameterError("created_after", s))
+			return
+		}
+	}
+
+	if s := c.Query("shuffled"); s != "" {
+		shuffled, err := strconv.ParseBool(s)
+		if err != nil {
+			respondWithError(c, invalidParameterError("shuffled", s))
+			return
+		}
+		filter.Shuffled = &shuffled
+	}
+
+	filter.MinRemaining, err = stringToOptionalCount("min_remaining", c.Query("min_remaining"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	filter.MaxRemaining, err = stringToOptionalCount("max_remaining", c.Query("max_remaining"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	filter.Labels, err = stringToLabels(c.Query("labels"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	decks, err := h.service.ListDecks(filter, c.Query("cursor"), limit)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, decks)
+}
+
+// Lists deck types
+func (h *handlers) listDeckTypes(c *gin.Context) {
+	types, err := h.service.ListDeckTypes()
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, types)
+}
+
+// Deletes a deck
+func (h *handlers) deleteDeck(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteDeck(id); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Closes a deck, which can still be read but no longer changed
+func (h *handlers) closeDeck(c *gin.Context) {
+	id := c.Param("id")
+
+	deck, err := h.serviceFor(c).CloseDeck(id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// Clones a deck into a new deck, optionally reshuffling its remaining cards
+func (h *handlers) cloneDeck(c *gin.Context) {
+	id := c.Param("id")
+
+	seed, err := stringToSeed(c.Query("seed"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	deck, err := h.serviceFor(c).CloneDeck(id, services.CloneOptions{
+		Reshuffle: stringToBoolDefault(c.DefaultQuery("reshuffle", "false"), false),
+		Seed:      seed,
+	})
+	if err != nil {
+		respondWithError(c, err)
 		return
 	}
 
@@ -56,9 +243,7 @@ func (h *handlers) openDeck(c *gin.Context) {
 
 	deck, err := h.service.OpenDeck(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondWithError(c, err)
 		return
 	}
 
@@ -68,25 +253,355 @@ func (h *handlers) openDeck(c *gin.Context) {
 // Draws cards
 func (h *handlers) drawCards(c *gin.Context) {
 	id := c.Param("id")
-	draw, err := strconv.ParseUint(c.Query("draw"), 10, 8)
+
+	codes, err := stringToCardCodeSlice(c.Query("cards"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondWithError(c, err)
 		return
 	}
 
-	cards, err := h.service.DrawCards(id, uint8(draw))
+	// The number of cards to draw is only needed when no cards are given
+	var draw int
+	if len(codes) == 0 {
+		draw, err = stringToCount("draw", c.Query("draw"))
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+	}
+
+	index, err := stringToCount("index", c.DefaultQuery("index", "0"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondWithError(c, err)
+		return
+	}
+
+	cards, err := h.serviceFor(c).DrawCards(id, services.DrawOptions{
+		Count:    draw,
+		Position: c.Query("position"),
+		Index:    index,
+		Cards:    codes,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cards)
+}
+
+// Peeks at cards without drawing them
+func (h *handlers) peekCards(c *gin.Context) {
+	id := c.Param("id")
+
+	count, err := stringToCount("count", c.Query("count"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	cards, err := h.service.PeekCards(id, count, c.Query("position"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cards)
+}
+
+// Returns drawn cards to a deck
+func (h *handlers) returnCards(c *gin.Context) {
+	id := c.Param("id")
+
+	cards, err := stringToCardCodeSlice(c.Query("cards"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	deck, err := h.serviceFor(c).ReturnCards(id, services.ReturnOptions{Cards: cards, Position: c.Query("position")})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// Shuffles the remaining cards of a deck
+func (h *handlers) shuffleDeck(c *gin.Context) {
+	id := c.Param("id")
+
+	seed, err := stringToSeed(c.Query("seed"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	deck, err := h.serviceFor(c).ShuffleDeck(id, services.ShuffleOptions{Seed: seed})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// Applies a cut or shuffle operation to the remaining cards of a deck
+func (h *handlers) applyOperation(c *gin.Context) {
+	id := c.Param("id")
+
+	var position *int
+	if c.Query("position") != "" {
+		p, err := strconv.Atoi(c.Query("position"))
+		if err != nil {
+			respondWithError(c, invalidParameterError("position", c.Query("position")))
+			return
+		}
+		position = &p
+	}
+
+	times, err := stringToCount("times", c.DefaultQuery("times", "1"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	deck, err := h.serviceFor(c).ApplyOperation(id, services.OperationOptions{
+		Operation: c.Query("operation"),
+		Position:  position,
+		Times:     times,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// Lists the history of a deck
+func (h *handlers) history(c *gin.Context) {
+	id := c.Param("id")
+
+	history, err := h.service.History(id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// Undoes the last operations of a deck
+func (h *handlers) undo(c *gin.Context) {
+	id := c.Param("id")
+
+	count, err := stringToCount("count", c.DefaultQuery("count", "1"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	deck, err := h.serviceFor(c).Undo(id, count)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// Deals cards to players
+func (h *handlers) deal(c *gin.Context) {
+	id := c.Param("id")
+
+	var players []string
+	if c.Query("players") != "" {
+		players = strings.Split(c.Query("players"), ",")
+	}
+
+	count, err := stringToCount("count", c.Query("count"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	burn, err := stringToCount("burn", c.DefaultQuery("burn", "0"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	hands, err := h.serviceFor(c).Deal(id, players, count, burn)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hands)
+}
+
+// Verifies the shuffle of a provably fair deck
+func (h *handlers) verifyDeck(c *gin.Context) {
+	id := c.Param("id")
+
+	verification, err := h.service.VerifyDeck(id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+// Adds cards to a pile
+func (h *handlers) addToPile(c *gin.Context) {
+	id := c.Param("id")
+
+	cards, err := stringToCardCodeSlice(c.Query("cards"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	// The number of cards to draw is only needed when no cards are given
+	var draw int
+	if len(cards) == 0 {
+		draw, err = stringToCount("draw", c.Query("draw"))
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+	}
+
+	pile, err := h.serviceFor(c).AddToPile(id, c.Param("name"), draw, cards)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pile)
+}
+
+// Lists the cards in a pile
+func (h *handlers) listPile(c *gin.Context) {
+	id := c.Param("id")
+
+	pile, err := h.service.ListPile(id, c.Param("name"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pile)
+}
+
+// Draws cards from a pile
+func (h *handlers) drawFromPile(c *gin.Context) {
+	id := c.Param("id")
+	draw, err := stringToCount("draw", c.Query("draw"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	cards, err := h.serviceFor(c).DrawFromPile(id, c.Param("name"), draw)
+	if err != nil {
+		respondWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, cards)
 }
 
+// Shuffles the cards in a pile
+func (h *handlers) shufflePile(c *gin.Context) {
+	id := c.Param("id")
+
+	seed, err := stringToSeed(c.Query("seed"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	pile, err := h.serviceFor(c).ShufflePile(id, c.Param("name"), services.ShuffleOptions{Seed: seed})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pile)
+}
+
+// Returns the cards of a pile to the deck
+func (h *handlers) returnPile(c *gin.Context) {
+	id := c.Param("id")
+
+	deck, err := h.serviceFor(c).ReturnPile(id, c.Param("name"), c.Query("position"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// Rejects requests to admin routes without the admin token
+func (h *handlers) requireAdmin(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if h.adminToken == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		respondWithError(c, &services.Error{
+			Kind:    ErrUnauthorized,
+			Message: "admin routes require a valid bearer token",
+		})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// Exports all decks as a snapshot file
+func (h *handlers) exportDecks(c *gin.Context) {
+	format := c.DefaultQuery("format", services.SnapshotFormatJsonl)
+
+	var contentType, fileName string
+	switch format {
+	case services.SnapshotFormatJsonl:
+		contentType, fileName = "application/x-ndjson", "decks.jsonl"
+	case services.SnapshotFormatGzip:
+		contentType, fileName = "application/gzip", "decks.jsonl.gz"
+	default:
+		respondWithError(c, invalidParameterError("format", format))
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Status(http.StatusOK)
+
+	// Once the snapshot is being streamed, a failure can only cut it short
+	if _, err := h.service.ExportDecks(c.Writer, format); err != nil {
+		c.Error(err)
+	}
+}
+
+// Imports the decks of a snapshot file, sent as the request body
+func (h *handlers) importDecks(c *gin.Context) {
+	imported, err := h.service.ImportDecks(c.Request.Body)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ImportDecksResponse{Imported: imported})
+}
+
+// Returns the service recording the actor of a request, given in the X-Actor header, in the history of decks
+func (h *handlers) serviceFor(c *gin.Context) services.DecksServicer {
+	return h.service.WithActor(c.GetHeader(ActorHeader))
+}
+
 func stringToBoolDefault(s string, def bool) bool {
 	var r bool
 	s = strings.ToLower(s)
@@ -100,6 +615,55 @@ func stringToBoolDefault(s string, def bool) bool {
 	return r
 }
 
+// Parses a non-negative number of cards or decks from a request parameter
+func stringToCount(field string, s string) (int, error) {
+	count, err := strconv.Atoi(s)
+	if err != nil || count < 0 {
+		return 0, invalidParameterError(field, s)
+	}
+	return count, nil
+}
+
+// Parses an optional non-negative number from a request parameter, nil if it is not given
+func stringToOptionalCount(field string, s string) (*int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	count, err := stringToCount(field, s)
+	if err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+
+// Parses an optional shuffle seed from a request parameter, nil if it is not given
+func stringToSeed(s string) (*int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	seed, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, invalidParameterError("seed", s)
+	}
+	return &seed, nil
+}
+
+// Parses labels from a request parameter, a comma separated list of key:value pairs, nil if it is empty
+func stringToLabels(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, invalidParameterError("labels", s)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
 func stringToCardCodeSlice(cards string) ([][2]rune, error) {
 	if cards == "" {
 		return make([][2]rune, 0), nil
@@ -107,10 +671,14 @@ func stringToCardCodeSlice(cards string) ([][2]rune, error) {
 	strings := strings.Split(cards, ",")
 	output := make([][2]rune, len(strings))
 	for i, s := range strings {
-		if len(s) != 2 {
-			return nil, fmt.Errorf("invalid card code: %s", s)
-		}
 		runes := []rune(s)
+		if len(runes) != 2 {
+			return nil, &services.Error{
+				Kind:    services.ErrInvalidCardCode,
+				Field:   "cards",
+				Message: fmt.Sprintf("invalid card code: %s", s),
+			}
+		}
 		output[i][0] = runes[0]
 		output[i][1] = runes[1]
 	}
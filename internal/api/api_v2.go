@@ -0,0 +1,254 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Version 2 of the API takes its parameters from JSON request bodies, which are validated strictly: unknown
+// fields, values of the wrong type and values out of range are rejected instead of being ignored or coerced.
+// Its responses hold the state of the deck right after the request, with the cards the request returned.
+
+// Creates a deck from a JSON request body
+func (h *handlers) createDeckV2(c *gin.Context) {
+	var request dto.CreateDeckRequest
+	if err := bindStrictJSON(c, &request); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	shuffle := true
+	if request.Shuffle != nil {
+		shuffle = *request.Shuffle
+	}
+
+	deckCount := 1
+	if request.DeckCount != nil {
+		deckCount = *request.DeckCount
+		if deckCount < 1 || deckCount > services.MaxDeckCount {
+			respondWithError(c, requestError("deck_count", "deck count must be between 1 and %d, got: %d", services.MaxDeckCount, deckCount))
+			return
+		}
+	}
+
+	cards, err := cardCodesOf(request.Cards)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	deck, err := h.serviceFor(c).CreateDeck(services.CreateDeckOptions{
+		Shuffle:    shuffle,
+		Cards:      cards,
+		DeckCount:  deckCount,
+		Type:       request.Type,
+		Seed:       request.Seed,
+		Fair:       request.Fair,
+		ClientSeed: request.ClientSeed,
+		Hidden:     request.Hidden,
+		Labels:     request.Labels,
+		State:      true,
+		Strict:     true,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, deckStateOf(deck.Deck, nil))
+}
+
+// Returns the state of a deck, with its remaining cards unless they are hidden
+func (h *handlers) getDeckV2(c *gin.Context) {
+	id := c.Param("id")
+
+	deck, err := h.service.DescribeDeck(id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// Draws either a number of cards or the given cards, from a JSON request body
+func (h *handlers) drawCardsV2(c *gin.Context) {
+	id := c.Param("id")
+
+	var request dto.DrawCardsRequest
+	if err := bindStrictJSON(c, &request); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	options := services.DrawOptions{Position: request.Position}
+	switch {
+	case request.Count != nil && request.Cards != nil:
+		respondWithError(c, requestError("cards", "either count or cards can be given, not both"))
+		return
+	case request.Count != nil:
+		if *request.Count < 1 {
+			respondWithError(c, requestError("count", "count must be at least 1, got: %d", *request.Count))
+			return
+		}
+		options.Count = *request.Count
+	case request.Cards != nil:
+		if request.Position != "" || request.Index != nil {
+			respondWithError(c, requestError("position", "cards are drawn wherever they are, a position can only be given with a count"))
+			return
+		}
+		cards, err := cardCodesOf(request.Cards)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		options.Cards = cards
+	default:
+		respondWithError(c, requestError("count", "either count or cards is required"))
+		return
+	}
+
+	if request.Index != nil {
+		if request.Position != services.PositionIndex {
+			respondWithError(c, requestError("index", "an index can only be given with the %s position", services.PositionIndex))
+			return
+		}
+		if *request.Index < 0 {
+			respondWithError(c, requestError("index", "index must be at least 0, got: %d", *request.Index))
+			return
+		}
+		options.Index = *request.Index
+	}
+
+	options.State = true
+	drawn, err := h.serviceFor(c).DrawCards(id, options)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deckStateOf(drawn.Deck, drawn.Cards))
+}
+
+// Returns drawn cards to a deck, from a JSON request body
+func (h *handlers) returnCardsV2(c *gin.Context) {
+	id := c.Param("id")
+
+	var request dto.ReturnCardsRequest
+	if err := bindStrictJSON(c, &request); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	cards, err := cardCodesOf(request.Cards)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	returned, err := h.serviceFor(c).ReturnCards(id, services.ReturnOptions{Cards: cards, Position: request.Position, State: true})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deckStateOf(returned.Deck, returned.Cards))
+}
+
+// Shuffles the remaining cards of a deck, from a JSON request body
+func (h *handlers) shuffleDeckV2(c *gin.Context) {
+	id := c.Param("id")
+
+	var request dto.ShuffleDeckRequest
+	if err := bindStrictJSON(c, &request); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	shuffled, err := h.serviceFor(c).ShuffleDeck(id, services.ShuffleOptions{Seed: request.Seed, State: true})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deckStateOf(shuffled.Deck, nil))
+}
+
+// Returns the state of a deck with the cards a request returned, if any
+func deckStateOf(deck *dto.DeckSummaryDto, cards []dto.CardDto) dto.DeckStateResponse {
+	result := dto.DeckStateResponse{Deck: *deck}
+	if len(cards) > 0 {
+		result.Cards = cards
+	}
+	return result
+}
+
+// Decodes a request body holding a single JSON object, rejecting unknown fields. An empty body is an empty object.
+func bindStrictJSON(c *gin.Context, request any) error {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return bodyError(err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return requestError("body", "the request body must hold a single JSON object")
+	}
+	return nil
+}
+
+// Returns the error for a request body that cannot be decoded, naming the offending field when it is known
+func bodyError(err error) error {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return requestError(typeError.Field, "invalid type for %s: expected %s, got %s", typeError.Field, typeError.Type, typeError.Value)
+	}
+	// The decoder reports unknown fields with a plain error
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field = strings.Trim(field, `"`)
+		return requestError(field, "unknown field: %s", field)
+	}
+	return requestError("body", "invalid JSON request body: %v", err)
+}
+
+// Parses a list of card codes from a request body, nil if it is omitted. A list that is given must not be empty.
+func cardCodesOf(cards []string) ([][2]rune, error) {
+	if cards == nil {
+		return nil, nil
+	}
+	if len(cards) == 0 {
+		return nil, requestError("cards", "the list of cards must not be empty")
+	}
+	result := make([][2]rune, len(cards))
+	for i, card := range cards {
+		runes := []rune(card)
+		if len(runes) != 2 {
+			return nil, &services.Error{
+				Kind:    services.ErrInvalidCardCode,
+				Field:   "cards",
+				Message: fmt.Sprintf("invalid card code: %s", card),
+			}
+		}
+		result[i] = [2]rune{runes[0], runes[1]}
+	}
+	return result, nil
+}
+
+// Returns the error for an invalid request body, or a value of it that is out of range or not allowed with other values
+func requestError(field string, format string, args ...any) error {
+	return &services.Error{
+		Kind:    services.ErrInvalidParameter,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Kinds of errors of the API itself, besides the errors of the service
+var (
+	ErrUnauthorized = errors.New("unauthorized") // The request lacks the token required by admin routes
+)
+
+// HTTP status and machine readable code for each kind of service error
+var errorMappings = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{services.ErrNotFound, http.StatusNotFound, "not_found"},
+	{services.ErrPileNotFound, http.StatusNotFound, "pile_not_found"},
+	{services.ErrInvalidId, http.StatusBadRequest, "invalid_id"},
+	{services.ErrInvalidCardCode, http.StatusBadRequest, "invalid_card_code"},
+	{services.ErrInvalidParameter, http.StatusBadRequest, "invalid_parameter"},
+	{ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{services.ErrDeckHidden, http.StatusForbidden, "deck_hidden"},
+	{services.ErrInsufficientCards, http.StatusConflict, "insufficient_cards"},
+	{services.ErrCardNotDrawn, http.StatusConflict, "card_not_drawn"},
+	{services.ErrCardNotInDeck, http.StatusConflict, "card_not_in_deck"},
+	{services.ErrNotProvablyFair, http.StatusConflict, "not_provably_fair"},
+	{services.ErrDeckClosed, http.StatusConflict, "deck_closed"},
+	{services.ErrEmptyDeck, http.StatusUnprocessableEntity, "empty_deck"},
+}
+
+// Responds with the status and error body matching the kind of error,
+// errors of unknown kinds are treated as internal server errors
+func respondWithError(c *gin.Context, err error) {
+	status, response := errorResponse(err)
+	c.JSON(status, response)
+}
+
+// Returns the HTTP status and error body for an error
+func errorResponse(err error) (int, dto.ErrorResponse) {
+	response := dto.ErrorResponse{
+		Code:    "internal_error",
+		Message: err.Error(),
+	}
+
+	var serviceError *services.Error
+	if errors.As(err, &serviceError) {
+		response.Field = serviceError.Field
+	}
+
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.kind) {
+			response.Code = mapping.code
+			return mapping.status, response
+		}
+	}
+
+	return http.StatusInternalServerError, response
+}
+
+// Creates an error for a request parameter that could not be parsed
+func invalidParameterError(field string, value string) error {
+	return &services.Error{
+		Kind:    services.ErrInvalidParameter,
+		Field:   field,
+		Message: "invalid value for " + field + ": " + value,
+	}
+}
@@ -0,0 +1,460 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// The OpenAPI 3 document is built from the description of each route below and from the dto types, whose
+// schemas are derived from their fields and JSON tags. Every route set up by SetupRoutes must be described.
+
+// Page rendering the OpenAPI document with Swagger UI
+//
+//go:embed openapi.html
+var openAPIPage []byte
+
+// Style sheet and script of Swagger UI, served along with the page, see swagger-ui/README.md
+var (
+	//go:embed swagger-ui/swagger-ui.css
+	swaggerUIStyle []byte
+	//go:embed swagger-ui/swagger-ui-bundle.js
+	swaggerUIScript []byte
+)
+
+// Parameter of a route, in its query string
+type routeParameter struct {
+	name        string
+	schema      string // JSON type of the parameter: "string", "integer" or "boolean"
+	description string
+}
+
+// Description of a route
+type routeDescription struct {
+	method      string
+	path        string // The path as set up with gin, with its parameters prefixed with ':'
+	tag         string
+	summary     string
+	parameters  []routeParameter
+	body        any    // The request DTO of a JSON request body, if any
+	rawBody     string // The content type of a request body that is not JSON, if any
+	status      int    // The status of successful responses
+	response    any    // The response DTO of a JSON response body, if any
+	rawResponse string // The content type of a response body that is not JSON, if any
+	admin       bool   // If the route requires the admin token
+}
+
+// Descriptions of the path parameters, by name
+var pathParameters = map[string]string{
+	"id":   "The ID (uuid) of the deck",
+	"name": "The name of the pile",
+}
+
+func query(name string, schema string, description string) routeParameter {
+	return routeParameter{name: name, schema: schema, description: description}
+}
+
+// Descriptions of all routes, in the order they are set up
+var routes = []routeDescription{
+	{
+		method: http.MethodPost, path: "/deck", tag: "decks", summary: "Creates a deck",
+		parameters: []routeParameter{
+			query("shuffle", "boolean", "If the deck is shuffled, true by default"),
+			query("cards", "string", "Comma separated list of the codes of the cards to use, all cards by default"),
+			query("deck_count", "integer", "Number of decks of a shoe, between 1 and 100, 1 by default"),
+			query("type", "string", "The name of the deck type, the default type by default"),
+			query("seed", "integer", "Seed of the shuffle, random by default"),
+			query("fair", "boolean", "If the deck is shuffled provably fair with the client seed"),
+			query("client_seed", "string", "Seed supplied by the client for a provably fair shuffle"),
+			query("hidden", "boolean", "If the remaining cards can only be seen by peeking"),
+			query("labels", "string", "Comma separated list of key:value labels"),
+		},
+		status: http.StatusOK, response: dto.CreateDeckResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/decks", tag: "decks", summary: "Lists the decks matching all given filters, a page at a time",
+		parameters: []routeParameter{
+			query("limit", "integer", "The maximum number of decks in the page, between 1 and 100, 20 by default"),
+			query("cursor", "string", "The next_cursor returned with the previous page"),
+			query("created_after", "string", "Only decks created after this RFC 3339 timestamp are listed"),
+			query("shuffled", "boolean", "Only shuffled or unshuffled decks are listed"),
+			query("min_remaining", "integer", "Only decks with at least this number of remaining cards are listed"),
+			query("max_remaining", "integer", "Only decks with at most this number of remaining cards are listed"),
+			query("type", "string", "Only decks of this type are listed"),
+			query("labels", "string", "Comma separated list of key:value labels the decks must all have"),
+		},
+		status: http.StatusOK, response: dto.ListDecksResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/deck-types", tag: "decks", summary: "Lists the deck types",
+		status: http.StatusOK, response: dto.DeckTypesResponse{},
+	},
+	{
+		method: http.MethodDelete, path: "/deck/:id", tag: "decks", summary: "Deletes a deck",
+		status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/close", tag: "decks", summary: "Closes a deck, so it can no longer be changed",
+		status: http.StatusOK, response: dto.CloseDeckResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/clone", tag: "decks", summary: "Creates a new deck in the exact state of a deck",
+		parameters: []routeParameter{
+			query("reshuffle", "boolean", "If the remaining cards of the clone are shuffled"),
+			query("seed", "integer", "Seed of the reshuffle, random by default"),
+		},
+		status: http.StatusOK, response: dto.CloneDeckResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/deck/:id/open", tag: "decks", summary: "Opens a deck, with its remaining cards",
+		status: http.StatusOK, response: dto.OpenDeckResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/draw-cards", tag: "cards", summary: "Draws a number of cards, or the given cards",
+		parameters: []routeParameter{
+			query("draw", "integer", "The number of cards to draw"),
+			query("position", "string", "Where the cards are drawn from: top, bottom, random or index"),
+			query("index", "integer", "With the index position, the index of the first card to draw"),
+			query("cards", "string", "Comma separated list of the codes of the cards to draw, instead of a number of cards"),
+		},
+		status: http.StatusOK, response: dto.DrawCardsResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/deck/:id/peek", tag: "cards", summary: "Peeks at cards without drawing them",
+		parameters: []routeParameter{
+			query("count", "integer", "The number of cards to peek at"),
+			query("position", "string", "Where the cards are peeked at: top or bottom"),
+		},
+		status: http.StatusOK, response: dto.PeekCardsResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/return", tag: "cards", summary: "Returns drawn cards to a deck",
+		parameters: []routeParameter{
+			query("cards", "string", "Comma separated list of the codes of the cards to return, all drawn cards by default"),
+			query("position", "string", "Where the cards are returned to: top, bottom or random"),
+		},
+		status: http.StatusOK, response: dto.ReturnCardsResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/shuffle", tag: "cards", summary: "Shuffles the remaining cards of a deck",
+		parameters: []routeParameter{
+			query("seed", "integer", "Seed of the shuffle, random by default"),
+		},
+		status: http.StatusOK, response: dto.ShuffleDeckResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/operations", tag: "cards", summary: "Applies a cut or shuffle operation to the remaining cards",
+		parameters: []routeParameter{
+			query("operation", "string", "The operation: "+strings.Join(services.Operations, ", ")),
+			query("position", "integer", "With the cut operation, the number of cards moved to the bottom"),
+			query("times", "integer", "The number of times the operation is applied, 1 by default"),
+		},
+		status: http.StatusOK, response: dto.DeckOperationResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/deck/:id/history", tag: "history", summary: "Lists the history of a deck",
+		status: http.StatusOK, response: dto.HistoryResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/undo", tag: "history", summary: "Undoes the last operations applied to a deck",
+		parameters: []routeParameter{
+			query("count", "integer", "The number of operations to undo, 1 by default"),
+		},
+		status: http.StatusOK, response: dto.UndoResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/deck/:id/verify", tag: "decks", summary: "Verifies the provably fair shuffle of a deck",
+		status: http.StatusOK, response: dto.VerifyDeckResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/deal", tag: "cards", summary: "Deals cards to players, round-robin",
+		parameters: []routeParameter{
+			query("players", "string", "Comma separated list of the names of the players"),
+			query("count", "integer", "The number of cards dealt to each player"),
+			query("burn", "integer", "The number of cards burned before dealing, 0 by default"),
+		},
+		status: http.StatusOK, response: dto.DealResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/pile/:name/add", tag: "piles", summary: "Adds drawn cards to a pile",
+		parameters: []routeParameter{
+			query("draw", "integer", "The number of cards to draw from the deck into the pile"),
+			query("cards", "string", "Comma separated list of the codes of drawn cards to add, instead of drawing cards"),
+		},
+		status: http.StatusOK, response: dto.PileResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/deck/:id/pile/:name/list", tag: "piles", summary: "Lists the cards of a pile",
+		status: http.StatusOK, response: dto.PileResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/pile/:name/draw", tag: "piles", summary: "Draws cards from the top of a pile",
+		parameters: []routeParameter{
+			query("draw", "integer", "The number of cards to draw"),
+		},
+		status: http.StatusOK, response: dto.DrawCardsResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/pile/:name/shuffle", tag: "piles", summary: "Shuffles the cards of a pile",
+		parameters: []routeParameter{
+			query("seed", "integer", "Seed of the shuffle, random by default"),
+		},
+		status: http.StatusOK, response: dto.PileResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/deck/:id/pile/:name/return", tag: "piles", summary: "Returns the cards of a pile to the deck",
+		parameters: []routeParameter{
+			query("position", "string", "Where the cards are returned to: top, bottom or random"),
+		},
+		status: http.StatusOK, response: dto.ReturnCardsResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v2/decks", tag: "v2", summary: "Creates a deck",
+		body: dto.CreateDeckRequest{}, status: http.StatusCreated, response: dto.DeckStateResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/v2/decks/:id", tag: "v2", summary: "Returns a deck, with its remaining cards unless they are hidden",
+		status: http.StatusOK, response: dto.DeckStateResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v2/decks/:id/draw", tag: "v2", summary: "Draws either a number of cards or the given cards",
+		body: dto.DrawCardsRequest{}, status: http.StatusOK, response: dto.DeckStateResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v2/decks/:id/return", tag: "v2", summary: "Returns drawn cards to a deck",
+		body: dto.ReturnCardsRequest{}, status: http.StatusOK, response: dto.DeckStateResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v2/decks/:id/shuffle", tag: "v2", summary: "Shuffles the remaining cards of a deck",
+		body: dto.ShuffleDeckRequest{}, status: http.StatusOK, response: dto.DeckStateResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/openapi.json", tag: "documentation", summary: "Returns this OpenAPI document",
+		status: http.StatusOK, response: map[string]any{},
+	},
+	{
+		method: http.MethodGet, path: "/docs", tag: "documentation", summary: "Renders this OpenAPI document with Swagger UI",
+		status: http.StatusOK, rawResponse: "text/html",
+	},
+	{
+		method: http.MethodGet, path: "/docs/swagger-ui.css", tag: "documentation", summary: "Returns the style sheet of Swagger UI",
+		status: http.StatusOK, rawResponse: "text/css",
+	},
+	{
+		method: http.MethodGet, path: "/docs/swagger-ui-bundle.js", tag: "documentation", summary: "Returns the script of Swagger UI",
+		status: http.StatusOK, rawResponse: "text/javascript",
+	},
+	{
+		method: http.MethodGet, path: "/admin/export", tag: "admin", summary: "Exports all decks as a snapshot file",
+		parameters: []routeParameter{
+			query("format", "string", "The format of the snapshot: "+services.SnapshotFormatJsonl+" or "+services.SnapshotFormatGzip),
+		},
+		status: http.StatusOK, rawResponse: "application/x-ndjson", admin: true,
+	},
+	{
+		method: http.MethodPost, path: "/admin/import", tag: "admin", summary: "Imports the decks of a snapshot file",
+		rawBody: "application/x-ndjson", status: http.StatusOK, response: dto.ImportDecksResponse{}, admin: true,
+	},
+}
+
+// The OpenAPI document, built once
+var openAPIDocument = sync.OnceValue(buildOpenAPIDocument)
+
+// Returns the OpenAPI document describing all routes
+func (h *handlers) openAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, openAPIDocument())
+}
+
+// Returns the page rendering the OpenAPI document
+func (h *handlers) docs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openAPIPage)
+}
+
+// Returns the style sheet of Swagger UI
+func (h *handlers) docsStyle(c *gin.Context) {
+	c.Data(http.StatusOK, "text/css; charset=utf-8", swaggerUIStyle)
+}
+
+// Returns the script of Swagger UI
+func (h *handlers) docsScript(c *gin.Context) {
+	c.Data(http.StatusOK, "text/javascript; charset=utf-8", swaggerUIScript)
+}
+
+// Builds the OpenAPI document from the descriptions of the routes
+func buildOpenAPIDocument() map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]any{}
+
+	for _, route := range routes {
+		path, parameters := openAPIPath(route.path)
+		for _, parameter := range route.parameters {
+			parameters = append(parameters, map[string]any{
+				"name":        parameter.name,
+				"in":          "query",
+				"description": parameter.description,
+				"schema":      map[string]any{"type": parameter.schema},
+			})
+		}
+
+		operation := map[string]any{
+			"tags":        []string{route.tag},
+			"summary":     route.summary,
+			"operationId": operationId(route),
+			"responses": map[string]any{
+				strconv.Itoa(route.status): successResponse(route, schemas),
+				"default":                  map[string]any{"$ref": "#/components/responses/Error"},
+			},
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if route.body != nil {
+			operation["requestBody"] = map[string]any{
+				"content": map[string]any{"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(route.body), schemas)}},
+			}
+		} else if route.rawBody != "" {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{route.rawBody: map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}},
+			}
+		}
+		if route.admin {
+			operation["security"] = []map[string]any{{"admin": []string{}}}
+		}
+
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path].(map[string]any)[strings.ToLower(route.method)] = operation
+	}
+
+	// The codes of error bodies are those of the error mappings
+	schemaOf(reflect.TypeOf(dto.ErrorResponse{}), schemas)
+	codes := []string{"internal_error"}
+	for _, mapping := range errorMappings {
+		codes = append(codes, mapping.code)
+	}
+	schemas["ErrorResponse"].(map[string]any)["properties"].(map[string]any)["code"].(map[string]any)["enum"] = codes
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Decks API",
+			"version": "2",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "The request failed, see the code of the error",
+					"content":     map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/ErrorResponse"}}},
+				},
+			},
+			"securitySchemes": map[string]any{
+				"admin": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// Returns the OpenAPI path of a gin path, with the descriptions of its parameters
+func openAPIPath(path string) (string, []any) {
+	var parameters []any
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+			parameters = append(parameters, map[string]any{
+				"name":        name,
+				"in":          "path",
+				"required":    true,
+				"description": pathParameters[name],
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// Returns a unique name of the operation of a route, such as "postV2DecksIdDraw"
+func operationId(route routeDescription) string {
+	id := strings.ToLower(route.method)
+	for _, word := range strings.FieldsFunc(route.path, func(r rune) bool { return strings.ContainsRune("/:-.", r) }) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return id
+}
+
+// Returns the successful response of a route
+func successResponse(route routeDescription, schemas map[string]any) map[string]any {
+	response := map[string]any{"description": http.StatusText(route.status)}
+	if route.response != nil {
+		response["content"] = map[string]any{"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(route.response), schemas)}}
+	} else if route.rawResponse != "" {
+		response["content"] = map[string]any{route.rawResponse: map[string]any{"schema": map[string]any{"type": "string"}}}
+	}
+	return response
+}
+
+// Returns the schema of a type. Structs are added to the schemas by name, and referred to.
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), schemas)
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			// The struct is added before its fields, so that recursive structs end
+			schema := map[string]any{"type": "object"}
+			schemas[t.Name()] = schema
+			properties := map[string]any{}
+			var required []string
+			for i := 0; i < t.NumField(); i++ {
+				name, options, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+				if name == "-" || !t.Field(i).IsExported() {
+					continue
+				}
+				if name == "" {
+					name = t.Field(i).Name
+				}
+				properties[name] = schemaOf(t.Field(i).Type, schemas)
+				if options != "omitempty" && t.Field(i).Type.Kind() != reflect.Pointer {
+					required = append(required, name)
+				}
+			}
+			schema["properties"] = properties
+			if len(required) > 0 {
+				schema["required"] = required
+			}
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
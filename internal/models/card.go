@@ -2,6 +2,6 @@ package models
 
 // Represents one card
 type Card struct {
-	SuitCode  rune // The code of the suit of this card
-	ValueCode rune // the code of the value of this card
+	Code [2]rune // The code of this card, its value code followed by its suit code
+	Deck int     // The number of the deck of a shoe this card comes from, starting at 1, or 0 if the deck is not a shoe
 }
@@ -3,4 +3,5 @@ package configs
 // Represents the configuration for the API
 type ApiConfig struct {
 	ServerPort string `yaml:"server_port"` // The port on which the API is served
+	AdminToken string `yaml:"admin_token"` // Bearer token required by the admin routes, which are disabled if it is empty
 }
@@ -3,5 +3,6 @@ package configs
 // All configuration data for api and decks service
 type Config struct {
 	Api   ApiConfig   `yaml:"api"`
+	Store StoreConfig `yaml:"store"`
 	Decks DecksConfig `yaml:"decks"`
 }
@@ -0,0 +1,10 @@
+package configs
+
+// Configuration of a named type of deck
+type DeckTypeConfig struct {
+	// The first character of the suits and values names will be taken to form codes of cards
+	// That means that all suits and values respectively need to start with different letters
+	Suits  []string          `yaml:"suits"`  // list of names of suits
+	Values []string          `yaml:"values"` // list of names of values
+	Extras []ExtraCardConfig `yaml:"extras"` // list of cards outside the grid of suits and values, which follow all other cards
+}
@@ -2,8 +2,13 @@ package configs
 
 // Confiuration for the decks service
 type DecksConfig struct {
+	// The suits, values and extras define the standard deck type
 	// The first character of the suits and values names will be taken to form codes of cards
 	// That means that all suits and values respectively need to start with different letters
-	Suits  []string `yaml:"suits"`  // list of names of suits
-	Values []string `yaml:"values"` // list of names of values
+	Suits       []string                  `yaml:"suits"`        // list of names of suits
+	Values      []string                  `yaml:"values"`       // list of names of values
+	Extras      []ExtraCardConfig         `yaml:"extras"`       // list of cards outside the grid of suits and values, which follow all other cards
+	Types       map[string]DeckTypeConfig `yaml:"types"`        // additional named deck types
+	DefaultType string                    `yaml:"default_type"` // name of the deck type used when none is requested, the standard type if empty
+	Random      RandomConfig              `yaml:"random"`       // source of random numbers of shuffles
 }
@@ -0,0 +1,8 @@
+package configs
+
+// Configuration of a card outside the grid of suits and values, such as a joker
+type ExtraCardConfig struct {
+	Code  string `yaml:"code"`  // The two-character code of the card, which must not be the code of any other card
+	Value string `yaml:"value"` // The name of the card
+	Suit  string `yaml:"suit"`  // The name of the suit of the card, if it has one
+}
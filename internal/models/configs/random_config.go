@@ -0,0 +1,7 @@
+package configs
+
+// Configuration of the source of random numbers of shuffles
+type RandomConfig struct {
+	Source string `yaml:"source"` // "crypto" (default) or "seeded"
+	Seed   int64  `yaml:"seed"`   // The seed of the "seeded" source
+}
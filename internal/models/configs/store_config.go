@@ -0,0 +1,12 @@
+package configs
+
+import "time"
+
+// Configuration for the decks repository
+type StoreConfig struct {
+	Type               string        `yaml:"type"`                // The repository implementation, either "memory" (default), "sqlite" or "journal"
+	Path               string        `yaml:"path"`                // Path to the database file of the sqlite repository, or to the directory of the journal repository
+	TTL                time.Duration `yaml:"ttl"`                 // Decks not accessed for this long are evicted by the memory repository, zero disables expiry
+	EvictionInterval   time.Duration `yaml:"eviction_interval"`   // How often the memory repository looks for expired decks
+	CompactionInterval time.Duration `yaml:"compaction_interval"` // How often the journal repository compacts its journal into a snapshot
+}
@@ -1,11 +1,25 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // A deck of cards
 type Deck struct {
-	DeckId    uuid.UUID // The deck's Id
-	Cards     [][2]rune // Card code
-	Shuffled  bool      // Indicates if the deck has been shuffled
-	Remaining uint8     // Number of remaining cards
+	DeckId       uuid.UUID         // The deck's Id
+	Cards        []Card            // The cards, drawn cards first, followed by the remaining cards
+	Shuffled     bool              // Indicates if the deck has been shuffled
+	Remaining    int               // Number of remaining cards
+	Type         string            // Name of the deck type the cards come from
+	Seed         *int64            // Seed of the last shuffle of the remaining cards, nil if it was not seeded
+	Fairness     *Fairness         // The provably fair shuffle of the deck, nil if the deck is not provably fair
+	Hidden       bool              // Indicates if the remaining cards can only be seen by peeking, and not by opening the deck
+	Labels       map[string]string // Labels given to the deck when it was created, to find it when listing decks
+	Closed       bool              // Indicates if the deck is closed, and can no longer be changed
+	Piles        map[string][]Card // Named piles of cards taken out of the deck, each from bottom to top
+	History      []DeckEvent       // Operations applied to the deck, oldest first
+	CreatedAt    time.Time         // When the deck was created
+	LastAccessed time.Time         // When the deck was last read or modified
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// An operation applied to a deck, as recorded in the deck's history
+type DeckEvent struct {
+	Type    string            // Kind of operation, such as "create", "draw" or "cut"
+	Time    time.Time         // When the operation was applied
+	Actor   string            // Who applied the operation, empty if unknown
+	Details map[string]string // Parameters of the operation, nil if it has none
+	Change  DeckChange        // How the operation changed the deck
+}
+
+// The state of a deck after an event, limited to what the event changed. Replaying the changes of the
+// events of a deck in order, starting with its creation, rebuilds the deck's cards and piles.
+type DeckChange struct {
+	Cards        []Card            // All cards of the deck, drawn first, nil if their order did not change
+	Remaining    int               // Number of remaining cards
+	Shuffled     bool              // If the deck has been shuffled
+	Seed         *int64            // Seed of the last shuffle of the remaining cards
+	Piles        map[string][]Card // Cards of the piles that were created or changed, nil if none were
+	RemovedPiles []string          // Names of the piles that were removed
+}
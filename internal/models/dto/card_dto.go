@@ -2,7 +2,8 @@ package dto
 
 // Represents one card
 type CardDto struct {
-	Suit  string `json:"suit"`  // The suit of this card
-	Value string `json:"value"` // The value (full name) of this card
-	Code  string `json:"code"`  // the code of this card
+	Suit  string `json:"suit"`           // The suit of this card
+	Value string `json:"value"`          // The value (full name) of this card
+	Code  string `json:"code"`           // the code of this card
+	Deck  int    `json:"deck,omitempty"` // The number of the deck of a shoe this card comes from, starting at 1
 }
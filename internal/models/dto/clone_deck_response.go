@@ -0,0 +1,14 @@
+package dto
+
+// DTO for a cloned deck
+type CloneDeckResponse struct {
+	DeckId       string         `json:"deck_id"`         // The Id of the new deck (a uuid represented as string)
+	SourceDeckId string         `json:"source_deck_id"`  // The Id of the deck that was cloned
+	Shuffled     bool           `json:"shuffled"`        // If the deck has been shuffled
+	Remaining    int            `json:"remaining"`       // Number of remaining cards
+	Type         string         `json:"type"`            // The name of the deck type
+	Seed         *int64         `json:"seed,omitempty"`  // Seed of the last shuffle, if it was seeded
+	Hidden       bool           `json:"hidden"`          // If the cards can only be seen by peeking
+	Piles        map[string]int `json:"piles,omitempty"` // Number of cards in each pile of the deck
+	Reshuffled   bool           `json:"reshuffled"`      // If the remaining cards were shuffled when cloning
+}
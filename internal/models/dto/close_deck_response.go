@@ -0,0 +1,9 @@
+package dto
+
+// DTO for a closed deck
+type CloseDeckResponse struct {
+	DeckId    string       `json:"deck_id"`            // The Id of the deck (a uuid represented as string)
+	Remaining int          `json:"remaining"`          // Number of remaining cards, which can no longer be drawn
+	Closed    bool         `json:"closed"`             // If the deck is closed
+	Fairness  *FairnessDto `json:"fairness,omitempty"` // The provably fair shuffle, with its server seed revealed
+}
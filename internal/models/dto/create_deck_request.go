@@ -0,0 +1,14 @@
+package dto
+
+// DTO for the request body creating a deck
+type CreateDeckRequest struct {
+	Shuffle    *bool             `json:"shuffle,omitempty"`     // If the deck is shuffled, true if omitted
+	Cards      []string          `json:"cards,omitempty"`       // Codes of the cards to use, all cards if omitted
+	DeckCount  *int              `json:"deck_count,omitempty"`  // Number of decks of a shoe, a single deck if omitted
+	Type       string            `json:"type,omitempty"`        // The name of the deck type, the default type if omitted
+	Seed       *int64            `json:"seed,omitempty"`        // Seed of the shuffle, random if omitted
+	Fair       bool              `json:"fair,omitempty"`        // If the deck is shuffled provably fair with the client seed
+	ClientSeed string            `json:"client_seed,omitempty"` // Seed supplied by the client for a provably fair shuffle
+	Hidden     bool              `json:"hidden,omitempty"`      // If the remaining cards can only be seen by peeking
+	Labels     map[string]string `json:"labels,omitempty"`      // Labels of the deck, to find it when listing decks
+}
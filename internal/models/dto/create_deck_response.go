@@ -2,7 +2,13 @@ package dto
 
 // DTO for deck object
 type CreateDeckResponse struct {
-	DeckId    string `json:"deck_id"`   // The Id of the deck (a uuid represented as string)
-	Shuffled  bool   `json:"shuffled"`  // If the deck has been shuffled
-	Remaining uint8  `json:"remaining"` // Number of remaining cards
+	DeckId    string            `json:"deck_id"`            // The Id of the deck (a uuid represented as string)
+	Shuffled  bool              `json:"shuffled"`           // If the deck has been shuffled
+	Remaining int               `json:"remaining"`          // Number of remaining cards
+	Type      string            `json:"type"`               // The name of the deck type
+	Seed      *int64            `json:"seed,omitempty"`     // Seed of the last shuffle, if it was seeded
+	Fairness  *FairnessDto      `json:"fairness,omitempty"` // The provably fair shuffle, if the deck is provably fair
+	Hidden    bool              `json:"hidden"`             // If the cards can only be seen by peeking
+	Labels    map[string]string `json:"labels,omitempty"`   // Labels given to the deck
+	Deck      *DeckSummaryDto   `json:"deck,omitempty"`     // The deck right after the change, if requested
 }
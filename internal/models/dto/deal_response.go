@@ -0,0 +1,15 @@
+package dto
+
+// DTO for hand object
+type HandDto struct {
+	Player string    `json:"player"` // The name of the player, which is also the name of the pile holding the hand
+	Cards  []CardDto `json:"cards"`  // The cards in the hand, in the order they were dealt
+}
+
+// DTO for dealt cards
+type DealResponse struct {
+	DeckId    string    `json:"deck_id"`          // The Id of the deck (a uuid represented as string)
+	Remaining int       `json:"remaining"`        // Number of remaining cards in the deck
+	Burned    []CardDto `json:"burned,omitempty"` // The cards burned before dealing
+	Hands     []HandDto `json:"hands"`            // The hands of the players, in the order of the players
+}
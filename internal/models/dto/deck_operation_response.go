@@ -0,0 +1,10 @@
+package dto
+
+// DTO for an operation applied to the remaining cards of a deck
+type DeckOperationResponse struct {
+	DeckId    string `json:"deck_id"`   // The Id of the deck (a uuid represented as string)
+	Shuffled  bool   `json:"shuffled"`  // If the deck has been shuffled
+	Remaining int    `json:"remaining"` // Number of remaining cards
+	Operation string `json:"operation"` // The operation that was applied
+	Times     int    `json:"times"`     // Number of times the operation was applied
+}
@@ -0,0 +1,7 @@
+package dto
+
+// DTO for the state of a deck after a request, with the cards the request returned
+type DeckStateResponse struct {
+	Deck  DeckSummaryDto `json:"deck"`            // The deck, after the request
+	Cards []CardDto      `json:"cards,omitempty"` // The drawn, returned or remaining cards, depending on the request
+}
@@ -0,0 +1,14 @@
+package dto
+
+// DTO for deck type object
+type DeckTypeDto struct {
+	Name    string    `json:"name"`    // The name of the deck type
+	Default bool      `json:"default"` // If decks are of this type when no type is requested
+	Size    int       `json:"size"`    // Number of cards in a full deck of this type
+	Cards   []CardDto `json:"cards"`   // All cards of a full deck of this type, in unshuffled order
+}
+
+// DTO for list of deck types
+type DeckTypesResponse struct {
+	Types []DeckTypeDto `json:"types"` // All deck types, by name
+}
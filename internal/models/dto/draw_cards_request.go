@@ -0,0 +1,9 @@
+package dto
+
+// DTO for the request body drawing cards, with either a number of cards or the cards to draw
+type DrawCardsRequest struct {
+	Count    *int     `json:"count,omitempty"`    // Number of cards to draw
+	Cards    []string `json:"cards,omitempty"`    // Codes of the cards to draw, wherever they are in the remaining cards
+	Position string   `json:"position,omitempty"` // Where a number of cards is drawn from, the top if omitted
+	Index    *int     `json:"index,omitempty"`    // Index of the first card drawn at the "index" position, the top card being at 0
+}
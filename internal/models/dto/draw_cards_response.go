@@ -2,5 +2,6 @@ package dto
 
 // DTO for deck object
 type DrawCardsResponse struct {
-	Cards []CardDto `json:"cards"` // The cards
+	Cards []CardDto       `json:"cards"`          // The cards
+	Deck  *DeckSummaryDto `json:"deck,omitempty"` // The deck right after the change, if requested
 }
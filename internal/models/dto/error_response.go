@@ -0,0 +1,8 @@
+package dto
+
+// DTO for errors
+type ErrorResponse struct {
+	Code    string `json:"code"`            // Machine readable error code
+	Message string `json:"message"`         // Human readable description of the error
+	Field   string `json:"field,omitempty"` // The offending request field, if any
+}
@@ -0,0 +1,9 @@
+package dto
+
+// DTO for provably fair shuffle object
+type FairnessDto struct {
+	Commitment string `json:"commitment"`            // The SHA-256 hash of the server seed, hex encoded
+	ClientSeed string `json:"client_seed"`           // The seed supplied by the client
+	ServerSeed string `json:"server_seed,omitempty"` // The seed of the server, hex encoded, once revealed
+	Revealed   bool   `json:"revealed"`              // If the server seed has been revealed
+}
@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// DTO for the history of a deck
+type HistoryResponse struct {
+	DeckId string         `json:"deck_id"` // The Id of the deck (a uuid represented as string)
+	Events []DeckEventDto `json:"events"`  // The events, oldest first
+}
+
+// DTO for an event of the history of a deck
+type DeckEventDto struct {
+	Type      string            `json:"type"`              // Kind of operation, such as "create", "draw" or "cut"
+	Time      time.Time         `json:"time"`              // When the operation was applied
+	Actor     string            `json:"actor,omitempty"`   // Who applied the operation, if known
+	Details   map[string]string `json:"details,omitempty"` // Parameters of the operation
+	Remaining int               `json:"remaining"`         // Number of remaining cards after the operation
+	Undone    bool              `json:"undone"`            // If the operation has been undone
+}
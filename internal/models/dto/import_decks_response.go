@@ -0,0 +1,6 @@
+package dto
+
+// DTO for imported decks
+type ImportDecksResponse struct {
+	Imported int `json:"imported"` // Number of decks imported
+}
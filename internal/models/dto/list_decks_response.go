@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+// DTO for a page of listed decks
+type ListDecksResponse struct {
+	Decks      []DeckSummaryDto `json:"decks"`                 // The decks, in the order of their ids
+	NextCursor string           `json:"next_cursor,omitempty"` // Cursor of the next page, if more decks match
+}
+
+// DTO for a deck without its cards
+type DeckSummaryDto struct {
+	DeckId       string            `json:"deck_id"`            // The Id of the deck (a uuid represented as string)
+	Shuffled     bool              `json:"shuffled"`           // If the deck has been shuffled
+	Remaining    int               `json:"remaining"`          // Number of remaining cards
+	Drawn        int               `json:"drawn"`              // Number of drawn cards
+	Type         string            `json:"type"`               // The name of the deck type
+	Seed         *int64            `json:"seed,omitempty"`     // Seed of the last shuffle, if it was seeded
+	Fairness     *FairnessDto      `json:"fairness,omitempty"` // The provably fair shuffle, if the deck is provably fair
+	Hidden       bool              `json:"hidden"`             // If the cards can only be seen by peeking
+	Labels       map[string]string `json:"labels,omitempty"`   // Labels given to the deck when it was created
+	Piles        map[string]int    `json:"piles,omitempty"`    // Number of cards in each pile of the deck
+	Closed       bool              `json:"closed"`             // If the deck is closed, and can no longer be changed
+	CreatedAt    time.Time         `json:"created_at"`         // When the deck was created
+	LastAccessed time.Time         `json:"last_accessed"`      // When the deck was last read or modified
+}
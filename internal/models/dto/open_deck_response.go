@@ -2,8 +2,13 @@ package dto
 
 // DTO for deck object
 type OpenDeckResponse struct {
-	DeckId    string    `json:"deck_id"`   // The Id of the deck (a uuid represented as string)
-	Shuffled  bool      `json:"shuffled"`  // If the deck has been shuffled
-	Remaining uint8     `json:"remaining"` // Number of remaining cards
-	Cards     []CardDto `json:"cards"`     // The cards
+	DeckId    string         `json:"deck_id"`            // The Id of the deck (a uuid represented as string)
+	Shuffled  bool           `json:"shuffled"`           // If the deck has been shuffled
+	Remaining int            `json:"remaining"`          // Number of remaining cards
+	Type      string         `json:"type"`               // The name of the deck type
+	Seed      *int64         `json:"seed,omitempty"`     // Seed of the last shuffle, if it was seeded
+	Fairness  *FairnessDto   `json:"fairness,omitempty"` // The provably fair shuffle, if the deck is provably fair
+	Cards     []CardDto      `json:"cards"`              // The cards
+	Piles     map[string]int `json:"piles,omitempty"`    // Number of cards in each pile of the deck
+	Closed    bool           `json:"closed,omitempty"`   // If the deck is closed, and can no longer be changed
 }
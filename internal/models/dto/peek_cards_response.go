@@ -0,0 +1,8 @@
+package dto
+
+// DTO for peeked cards
+type PeekCardsResponse struct {
+	DeckId    string    `json:"deck_id"`   // The Id of the deck (a uuid represented as string)
+	Remaining int       `json:"remaining"` // Number of remaining cards, which is not changed by peeking
+	Cards     []CardDto `json:"cards"`     // The cards, in the order they would be drawn
+}
@@ -0,0 +1,9 @@
+package dto
+
+// DTO for pile object
+type PileResponse struct {
+	DeckId    string    `json:"deck_id"`   // The Id of the deck (a uuid represented as string)
+	Remaining int       `json:"remaining"` // Number of remaining cards in the deck
+	Pile      string    `json:"pile"`      // The name of the pile
+	Cards     []CardDto `json:"cards"`     // The cards in the pile, from bottom to top
+}
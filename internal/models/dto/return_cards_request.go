@@ -0,0 +1,7 @@
+package dto
+
+// DTO for the request body returning drawn cards
+type ReturnCardsRequest struct {
+	Cards    []string `json:"cards,omitempty"`    // Codes of the cards to return, all drawn cards if omitted
+	Position string   `json:"position,omitempty"` // Where the cards are returned to, the top if omitted
+}
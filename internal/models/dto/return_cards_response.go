@@ -0,0 +1,10 @@
+package dto
+
+// DTO for deck object
+type ReturnCardsResponse struct {
+	DeckId    string          `json:"deck_id"`        // The Id of the deck (a uuid represented as string)
+	Shuffled  bool            `json:"shuffled"`       // If the deck has been shuffled
+	Remaining int             `json:"remaining"`      // Number of remaining cards
+	Cards     []CardDto       `json:"cards"`          // The returned cards
+	Deck      *DeckSummaryDto `json:"deck,omitempty"` // The deck right after the change, if requested
+}
@@ -0,0 +1,6 @@
+package dto
+
+// DTO for the request body shuffling a deck
+type ShuffleDeckRequest struct {
+	Seed *int64 `json:"seed,omitempty"` // Seed of the shuffle, random if omitted
+}
@@ -0,0 +1,10 @@
+package dto
+
+// DTO for deck object
+type ShuffleDeckResponse struct {
+	DeckId    string          `json:"deck_id"`        // The Id of the deck (a uuid represented as string)
+	Shuffled  bool            `json:"shuffled"`       // If the deck has been shuffled
+	Remaining int             `json:"remaining"`      // Number of remaining cards
+	Seed      *int64          `json:"seed,omitempty"` // Seed of the last shuffle, if it was seeded
+	Deck      *DeckSummaryDto `json:"deck,omitempty"` // The deck right after the change, if requested
+}
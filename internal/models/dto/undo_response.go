@@ -0,0 +1,9 @@
+package dto
+
+// DTO for undone operations
+type UndoResponse struct {
+	DeckId    string `json:"deck_id"`   // The Id of the deck (a uuid represented as string)
+	Shuffled  bool   `json:"shuffled"`  // If the deck has been shuffled
+	Remaining int    `json:"remaining"` // Number of remaining cards
+	Undone    int    `json:"undone"`    // Number of operations that were undone
+}
@@ -0,0 +1,10 @@
+package dto
+
+// DTO for verification of a provably fair deck
+type VerifyDeckResponse struct {
+	DeckId        string      `json:"deck_id"`                  // The Id of the deck (a uuid represented as string)
+	Fairness      FairnessDto `json:"fairness"`                 // The seeds and commitment of the shuffle
+	Verified      bool        `json:"verified"`                 // If the revealed server seed matches the commitment
+	InitialCards  []CardDto   `json:"initial_cards"`            // The cards of the deck before shuffling
+	ShuffledCards []CardDto   `json:"shuffled_cards,omitempty"` // The cards shuffled with the seeds, once revealed
+}
@@ -0,0 +1,10 @@
+package models
+
+// The provably fair shuffle of a deck, committed to before the deck was shuffled
+type Fairness struct {
+	ServerSeed   string // The secret seed of the server, hex encoded, only shown once revealed
+	Commitment   string // The SHA-256 hash of the server seed, hex encoded, published at creation
+	ClientSeed   string // The seed supplied by the client, mixed with the server seed
+	Revealed     bool   // Indicates if the server seed has been revealed, once the deck is exhausted or closed
+	InitialCards []Card // The cards of the deck before shuffling
+}
@@ -0,0 +1,17 @@
+package services
+
+import "time"
+
+// Source of the current time, replaceable in tests
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Clock reading the system time
+var SystemClock Clock = systemClock{}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Cloning a deck creates a new deck in the state the source deck is in, read at once so that concurrent changes
+// of the source are either entirely included or not at all. The clone keeps the drawn and remaining cards, piles,
+// type, labels and visibility of the source, and starts its own history. It is never closed nor provably fair.
+//
+// The remaining cards of a clone are as unknown as those of the source only if they are reshuffled. Hidden decks
+// can therefore only be cloned with a reshuffle, otherwise drawing from the clone would reveal the next cards of
+// the source. The cards of other decks, provably fair ones included, can be seen by opening them anyway, and
+// the server seed of a provably fair deck is never copied to its clone.
+
+// Options for cloning a deck
+type CloneOptions struct {
+	Reshuffle bool   // If the remaining cards of the clone are shuffled, drawn cards and piles are kept as they are
+	Seed      *int64 // Seed of the reshuffle, random if nil
+}
+
+// Clones a deck into a new deck
+func (ds *DecksService) CloneDeck(deckId string, options CloneOptions) (*dto.CloneDeckResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Seed != nil && !options.Reshuffle {
+		return nil, newError(ErrInvalidParameter, "seed", "a seed can only be used to reshuffle a clone")
+	}
+
+	source, err := ds.decks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if source.Hidden && !options.Reshuffle {
+		return nil, newError(ErrInvalidParameter, "reshuffle", "the cards of deck id %s are hidden, it can only be cloned with a reshuffle", deckId)
+	}
+
+	// The source is a copy, which the clone can take over
+	clone := models.Deck{
+		Cards:     source.Cards,
+		Shuffled:  source.Shuffled,
+		Remaining: source.Remaining,
+		Type:      source.Type,
+		Seed:      source.Seed,
+		Hidden:    source.Hidden,
+		Labels:    source.Labels,
+		Piles:     source.Piles,
+	}
+	if options.Reshuffle {
+		shuffleCards(clone.Cards[len(clone.Cards)-clone.Remaining:], ds.shuffleSource(options.Seed))
+		clone.Shuffled = true
+		clone.Seed = options.Seed
+	}
+
+	details := map[string]string{
+		"clone_of":  source.DeckId.String(),
+		"reshuffle": strconv.FormatBool(options.Reshuffle),
+	}
+	if options.Seed != nil {
+		details["seed"] = strconv.FormatInt(*options.Seed, 10)
+	}
+	clone.History = []models.DeckEvent{
+		ds.newEvent(EventCreate, details, changeOf(&models.Deck{}, &clone)),
+	}
+
+	if _, err := ds.decks.Create(&clone); err != nil {
+		return nil, err
+	}
+
+	result := dto.CloneDeckResponse{
+		DeckId:       clone.DeckId.String(),
+		SourceDeckId: source.DeckId.String(),
+		Shuffled:     clone.Shuffled,
+		Remaining:    clone.Remaining,
+		Type:         ds.deckTypeOf(&clone).name,
+		Seed:         clone.Seed,
+		Hidden:       clone.Hidden,
+		Piles:        pileSizes(&clone),
+		Reshuffled:   options.Reshuffle,
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Deals cards to players round-robin, one card at a time, like a physical dealer, after burning the given number
+// of cards off the top of the deck. Burned cards are drawn from the deck. The hand of each player is a new pile
+// named after the player, so players must not be named like a pile of the deck, including the hand of a previous
+// deal. Either all cards are dealt, or none are.
+func (ds *DecksService) Deal(deckId string, players []string, count int, burn int) (*dto.DealResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePlayers(players); err != nil {
+		return nil, err
+	}
+	if count < 1 {
+		return nil, newError(ErrInvalidParameter, "count", "invalid number of cards per player: %d", count)
+	}
+	if burn < 0 {
+		return nil, newError(ErrInvalidParameter, "burn", "invalid number of cards to burn: %d", burn)
+	}
+
+	var burned []models.Card
+
+	details := map[string]string{
+		"players": strings.Join(players, ","),
+		"count":   strconv.Itoa(count),
+		"burn":    strconv.Itoa(burn),
+	}
+
+	deck, err := ds.updateDeck(id, EventDeal, details, func(deck *models.Deck) error {
+		for _, player := range players {
+			if _, ok := deck.Piles[player]; ok {
+				return newError(ErrInvalidParameter, "players", "player %s is named like a pile of deck id %s", player, deckId)
+			}
+		}
+
+		needed := burn + count*len(players)
+		if deck.Remaining < needed {
+			return newError(ErrInsufficientCards, "count", "%d card(s) needed to deal, but deck id %s has only %d card(s) left", needed, deckId, deck.Remaining)
+		}
+
+		skip := len(deck.Cards) - deck.Remaining
+		drawn := copyCards(deck.Cards[:skip])
+		remaining := deck.Cards[skip:]
+
+		// Burned cards are drawn, just before the remaining cards like any drawn card
+		burned = copyCards(remaining[:burn])
+		drawn = append(drawn, burned...)
+		remaining = remaining[burn:]
+
+		if deck.Piles == nil {
+			deck.Piles = make(map[string][]models.Card)
+		}
+		for round := 0; round < count; round++ {
+			for _, player := range players {
+				deck.Piles[player] = append(deck.Piles[player], remaining[0])
+				remaining = remaining[1:]
+			}
+		}
+
+		deck.Cards = append(drawn, remaining...)
+		deck.Remaining = len(remaining)
+		revealIfExhausted(deck)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.DealResponse{
+		DeckId:    deck.DeckId.String(),
+		Remaining: deck.Remaining,
+		Burned:    ds.cardDtosFromIds(deck, burned),
+		Hands:     make([]dto.HandDto, len(players)),
+	}
+	for i, player := range players {
+		result.Hands[i] = dto.HandDto{
+			Player: player,
+			Cards:  ds.cardDtosFromIds(deck, deck.Piles[player]),
+		}
+	}
+
+	return &result, nil
+}
+
+// Validates the names of players to deal to, which must be given, and different from each other
+func validatePlayers(players []string) error {
+	if len(players) == 0 {
+		return newError(ErrInvalidParameter, "players", "no players to deal to")
+	}
+	seen := make(map[string]bool, len(players))
+	for _, player := range players {
+		if player == "" {
+			return newError(ErrInvalidParameter, "players", "invalid empty player name")
+		}
+		if seen[player] {
+			return newError(ErrInvalidParameter, "players", "duplicate player: %s", player)
+		}
+		seen[player] = true
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Position in the remaining cards at which cards are drawn at a given index, besides the top, bottom and random positions
+const PositionIndex = "index"
+
+// Options for drawing cards
+type DrawOptions struct {
+	Count    int       // Number of cards to draw
+	Position string    // Where cards are drawn from: the top (default), the bottom, random positions, or an index
+	Index    int       // Index in the remaining cards of the first card drawn at an index, the top card being at 0
+	Cards    [][2]rune // Codes of the cards to draw wherever they are in the remaining cards, instead of a number of cards
+	State    bool      // If the response holds the summary of the deck after drawing the cards
+}
+
+// Draws cards from the top, the bottom, random positions or an index of the remaining cards of a deck, or draws
+// the given cards. Cards are drawn in order: from the bottom, the bottom card is drawn first.
+func (ds *DecksService) DrawCards(deckId string, options DrawOptions) (*dto.DrawCardsResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := parseDrawPosition(options.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	draw := options.Count
+	if len(options.Cards) > 0 {
+		draw = len(options.Cards)
+	}
+	if draw < 0 {
+		return nil, newError(ErrInvalidParameter, "draw", "invalid number of cards to draw: %d", draw)
+	}
+	if options.Index < 0 {
+		return nil, newError(ErrInvalidParameter, "index", "invalid index: %d", options.Index)
+	}
+
+	details := map[string]string{"count": strconv.Itoa(draw), "position": position}
+	if len(options.Cards) > 0 {
+		details = map[string]string{"cards": codesDetail(options.Cards)}
+	} else if position == PositionIndex {
+		details["index"] = strconv.Itoa(options.Index)
+	}
+
+	var drawnCards []models.Card
+
+	// Checking and decreasing the remaining count happens atomically in the store,
+	// so concurrent draws from the same deck never hand out the same cards
+	deck, err := ds.updateDeck(id, EventDraw, details, func(deck *models.Deck) error {
+		skip := len(deck.Cards) - deck.Remaining
+		remaining := deck.Cards[skip:]
+
+		// Positions in the remaining cards of the drawn cards, in the order they are drawn
+		var positions []int
+		if len(options.Cards) > 0 {
+			cardPositions, err := positionsOfCards(deck, remaining, options.Cards, deckId)
+			if err != nil {
+				return err
+			}
+			positions = cardPositions
+		} else {
+			if deck.Remaining < draw {
+				return newError(ErrInsufficientCards, "draw", "%s card(s) requested, but deck id %s has only %s card(s) left", strconv.Itoa(draw), deckId, strconv.Itoa(deck.Remaining))
+			}
+			switch position {
+			case PositionTop:
+				positions = positionsInRange(0, draw)
+			case PositionBottom:
+				positions = positionsInRange(len(remaining)-1, len(remaining)-1-draw)
+			case PositionIndex:
+				if options.Index+draw > len(remaining) {
+					return newError(ErrInsufficientCards, "index", "%d card(s) requested at index %d, but deck id %s has only %d card(s) left", draw, options.Index, deckId, len(remaining))
+				}
+				positions = positionsInRange(options.Index, options.Index+draw)
+			case PositionRandom:
+				positions = randomPositions(len(remaining), draw, intnOf(ds.random))
+			}
+		}
+
+		var rest []models.Card
+		drawnCards, rest = takeCards(remaining, positions)
+
+		// Drawn cards are kept just before the remaining ones, in the order they were drawn
+		deck.Cards = append(append(copyCards(deck.Cards[:skip]), drawnCards...), rest...)
+		deck.Remaining = len(rest)
+
+		revealIfExhausted(deck)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.DrawCardsResponse{
+		Cards: ds.cardDtosFromIds(deck, drawnCards),
+		Deck:  ds.stateOf(options.State, deck),
+	}
+
+	return &result, nil
+}
+
+// Validates a position to draw cards from, defaulting to the top
+func parseDrawPosition(position string) (string, error) {
+	switch position {
+	case "":
+		return PositionTop, nil
+	case PositionTop, PositionBottom, PositionRandom, PositionIndex:
+		return position, nil
+	default:
+		return "", newError(ErrInvalidParameter, "position", "invalid position: %s", position)
+	}
+}
+
+// Returns the positions from start up to, but excluding, end, counting down if end is lower than start
+func positionsInRange(start int, end int) []int {
+	var result []int
+	for i := start; i != end; {
+		result = append(result, i)
+		if end > start {
+			i++
+		} else {
+			i--
+		}
+	}
+	return result
+}
+
+// Returns count distinct random positions among n positions, with random numbers in [0, n) taken from intn
+func randomPositions(n int, count int, intn func(n int) int) []int {
+	positions := positionsInRange(0, n)
+	// A Fisher-Yates shuffle, stopped after the first count positions
+	for i := 0; i < count; i++ {
+		j := i + intn(n-i)
+		positions[i], positions[j] = positions[j], positions[i]
+	}
+	return positions[:count]
+}
+
+// Returns the positions of the given cards in the remaining cards of a deck, or an error telling where a card
+// is if it is not among them. In a shoe, the first remaining card with a code is drawn.
+func positionsOfCards(deck *models.Deck, remaining []models.Card, codes [][2]rune, deckId string) ([]int, error) {
+	result := make([]int, len(codes))
+	taken := make(map[int]bool, len(codes))
+	for i, code := range codes {
+		result[i] = -1
+		for j, card := range remaining {
+			if card.Code == code && !taken[j] {
+				result[i] = j
+				taken[j] = true
+				break
+			}
+		}
+		if result[i] < 0 {
+			return nil, cardNotInDeckError(deck, code, deckId)
+		}
+	}
+	return result, nil
+}
+
+// Returns the error for a card missing from the remaining cards of a deck, telling where the card is
+func cardNotInDeckError(deck *models.Deck, code [2]rune, deckId string) error {
+	if indexOf(code, deck.Cards[:len(deck.Cards)-deck.Remaining]) >= 0 {
+		return newError(ErrCardNotInDeck, "cards", "card %s has already been drawn from deck id: %s", string(code[:]), deckId)
+	}
+	for _, pile := range sortedPileNames(deck) {
+		if indexOf(code, deck.Piles[pile]) >= 0 {
+			return newError(ErrCardNotInDeck, "cards", "card %s is in pile %s of deck id: %s", string(code[:]), pile, deckId)
+		}
+	}
+	return newError(ErrCardNotInDeck, "cards", "card %s is not part of deck id: %s", string(code[:]), deckId)
+}
+
+// Returns the cards at the given positions, in the order of the positions, and a new slice of the other cards
+func takeCards(cards []models.Card, positions []int) ([]models.Card, []models.Card) {
+	taken := make([]models.Card, len(positions))
+	isTaken := make(map[int]bool, len(positions))
+	for i, position := range positions {
+		taken[i] = cards[position]
+		isTaken[position] = true
+	}
+	rest := make([]models.Card, 0, len(cards)-len(positions))
+	for i, card := range cards {
+		if !isTaken[i] {
+			rest = append(rest, card)
+		}
+	}
+	return taken, rest
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"github.com/rnkjnk/decks-api/fairness"
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Provably fair decks are shuffled with a secret server seed, committed to at creation, and a seed supplied
+// by the client. The server seed is revealed once the deck is exhausted or closed, so that the order of the deck can
+// be verified with the fairness package.
+
+// Shuffles cards provably fair with a new server seed and the client seed
+func fairShuffle(cards []models.Card, clientSeed string) ([]models.Card, *models.Fairness, error) {
+	serverSeed, err := fairness.NewServerSeed()
+	if err != nil {
+		return nil, nil, err
+	}
+	commitment, err := fairness.Commit(serverSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+	shuffled, err := fairlyShuffledCards(cards, serverSeed, clientSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := models.Fairness{
+		ServerSeed:   serverSeed,
+		Commitment:   commitment,
+		ClientSeed:   clientSeed,
+		InitialCards: cards,
+	}
+
+	return shuffled, &result, nil
+}
+
+// Returns the cards shuffled with the given seeds
+func fairlyShuffledCards(cards []models.Card, serverSeed string, clientSeed string) ([]models.Card, error) {
+	permutation, err := fairness.Permutation(serverSeed, clientSeed, len(cards))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.Card, len(cards))
+	for i, j := range permutation {
+		result[i] = cards[j]
+	}
+	return result, nil
+}
+
+// Reveals the server seed of a provably fair deck once no cards remain
+func revealIfExhausted(deck *models.Deck) {
+	if deck.Fairness != nil && deck.Remaining == 0 {
+		deck.Fairness.Revealed = true
+	}
+}
+
+// Rejects changing the order of the remaining cards of a provably fair deck before its server seed is revealed,
+// since the cards dealt next would no longer be in the order committed to
+func checkFairOrder(deckId string, deck *models.Deck) error {
+	if deck.Fairness != nil && !deck.Fairness.Revealed {
+		return newError(ErrDeckClosed, "id", "the order of the cards of provably fair deck id %s cannot be changed before its server seed is revealed", deckId)
+	}
+	return nil
+}
+
+// Verifies the shuffle of a provably fair deck. Before the server seed is revealed, only the commitment, the client
+// seed and the cards before shuffling are returned.
+func (ds *DecksService) VerifyDeck(deckId string) (*dto.VerifyDeckResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	deck, err := ds.decks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if deck.Fairness == nil {
+		return nil, newError(ErrNotProvablyFair, "id", "deck id %s was not shuffled provably fair", deckId)
+	}
+
+	result := dto.VerifyDeckResponse{
+		DeckId:       deck.DeckId.String(),
+		Fairness:     *fairnessDtoFromDeck(deck),
+		InitialCards: ds.cardDtosFromIds(deck, deck.Fairness.InitialCards),
+	}
+
+	if deck.Fairness.Revealed {
+		commitment, err := fairness.Commit(deck.Fairness.ServerSeed)
+		if err != nil {
+			return nil, err
+		}
+		shuffled, err := fairlyShuffledCards(deck.Fairness.InitialCards, deck.Fairness.ServerSeed, deck.Fairness.ClientSeed)
+		if err != nil {
+			return nil, err
+		}
+		result.Verified = commitment == deck.Fairness.Commitment
+		result.ShuffledCards = ds.cardDtosFromIds(deck, shuffled)
+	}
+
+	return &result, nil
+}
+
+// Returns the DTO of the provably fair shuffle of a deck, with the server seed only if it has been revealed,
+// or nil if the deck is not provably fair
+func fairnessDtoFromDeck(deck *models.Deck) *dto.FairnessDto {
+	if deck.Fairness == nil {
+		return nil
+	}
+	result := dto.FairnessDto{
+		Commitment: deck.Fairness.Commitment,
+		ClientSeed: deck.Fairness.ClientSeed,
+		Revealed:   deck.Fairness.Revealed,
+	}
+	if deck.Fairness.Revealed {
+		result.ServerSeed = deck.Fairness.ServerSeed
+	}
+	return &result
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Every change to a deck is recorded as an event in the deck's history, with the state of the deck that
+// the change produced. Events are never modified once recorded. Undoing operations rebuilds the deck by
+// replaying the events that were not undone, and is itself recorded as an event, which cannot be undone.
+//
+// Histories are bounded: once a history holds more than MaxHistoryEvents events, its oldest events are merged
+// into its creation event, which then holds the state of the deck after them. Merged events are no longer
+// listed, and can no longer be undone.
+
+// Maximum number of events in the history of a deck
+const MaxHistoryEvents = 500
+
+// Types of the events of the history of a deck, besides the names of operations
+const (
+	EventCreate      = "create"
+	EventDraw        = "draw"
+	EventReturn      = "return"
+	EventShuffle     = "shuffle"
+	EventDeal        = "deal"
+	EventPileAdd     = "pile_add"
+	EventPileDraw    = "pile_draw"
+	EventPileShuffle = "pile_shuffle"
+	EventPileReturn  = "pile_return"
+	EventUndo        = "undo"
+	EventClose       = "close"
+)
+
+// Returns a service that records the given actor in the events of the decks it changes
+func (ds *DecksService) WithActor(actor string) DecksServicer {
+	result := *ds
+	result.actor = actor
+	return &result
+}
+
+// Returns the history of a deck, oldest event first
+func (ds *DecksService) History(deckId string) (*dto.HistoryResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	deck, err := ds.decks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	undone := make(map[int]bool)
+	for i := range deck.History {
+		undone[i] = true
+	}
+	for _, i := range activeEvents(deck.History) {
+		undone[i] = false
+	}
+
+	events := make([]dto.DeckEventDto, len(deck.History))
+	for i, event := range deck.History {
+		events[i] = dto.DeckEventDto{
+			Type:      event.Type,
+			Time:      event.Time,
+			Actor:     event.Actor,
+			Details:   event.Details,
+			Remaining: event.Change.Remaining,
+			Undone:    undone[i] && event.Type != EventUndo,
+		}
+	}
+
+	result := dto.HistoryResponse{
+		DeckId: deck.DeckId.String(),
+		Events: events,
+	}
+
+	return &result, nil
+}
+
+// Reverts the last operations of a deck, that have not been undone yet. The deck's creation cannot be undone, nor
+// can the operations of a provably fair deck once its server seed is revealed, as the seed would then deal known cards.
+func (ds *DecksService) Undo(deckId string, count int) (*dto.UndoResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	if count < 1 {
+		return nil, newError(ErrInvalidParameter, "count", "invalid number of operations to undo: %d", count)
+	}
+
+	details := map[string]string{"count": strconv.Itoa(count)}
+
+	deck, err := ds.updateDeck(id, EventUndo, details, func(deck *models.Deck) error {
+		if deck.Fairness != nil && deck.Fairness.Revealed {
+			return newError(ErrDeckClosed, "", "the server seed of deck id %s is revealed, its operations can no longer be undone", deckId)
+		}
+
+		active := activeEvents(deck.History)
+		// Only decks with a complete history, starting with their creation, can be rebuilt
+		undoable := 0
+		if len(active) > 0 && deck.History[active[0]].Type == EventCreate {
+			undoable = len(active) - 1
+		}
+		if count > undoable {
+			return newError(ErrInvalidParameter, "count", "%d operation(s) requested, but only %d operation(s) of deck id %s can be undone", count, undoable, deckId)
+		}
+
+		var replayed models.Deck
+		for _, i := range active[:len(active)-count] {
+			applyChange(&replayed, deck.History[i].Change)
+		}
+		deck.Cards = replayed.Cards
+		deck.Remaining = replayed.Remaining
+		deck.Shuffled = replayed.Shuffled
+		deck.Seed = replayed.Seed
+		deck.Piles = replayed.Piles
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.UndoResponse{
+		DeckId:    deck.DeckId.String(),
+		Shuffled:  deck.Shuffled,
+		Remaining: deck.Remaining,
+		Undone:    count,
+	}
+
+	return &result, nil
+}
+
+// Updates a deck like DecksStorer.Update, and records the update as an event of the given type in the
+// deck's history when it succeeds. Closed decks cannot be updated.
+func (ds *DecksService) updateDeck(id uuid.UUID, event string, details map[string]string, update func(*models.Deck) error) (*models.Deck, error) {
+	return ds.decks.Update(id, func(deck *models.Deck) error {
+		if deck.Closed {
+			return closedError(deck)
+		}
+		before := copyDeck(deck)
+		if err := update(deck); err != nil {
+			return err
+		}
+		deck.History = compactHistory(append(deck.History, ds.newEvent(event, details, changeOf(before, deck))))
+		return nil
+	})
+}
+
+// Creates an event applied by the actor of the service now
+func (ds *DecksService) newEvent(event string, details map[string]string, change models.DeckChange) models.DeckEvent {
+	return models.DeckEvent{
+		Type:    event,
+		Time:    ds.clock.Now().UTC(),
+		Actor:   ds.actor,
+		Details: details,
+		Change:  change,
+	}
+}
+
+// Returns the change from one state of a deck to another, with copies of the cards that changed
+func changeOf(before *models.Deck, after *models.Deck) models.DeckChange {
+	change := models.DeckChange{
+		Remaining: after.Remaining,
+		Shuffled:  after.Shuffled,
+		Seed:      after.Seed,
+	}
+	if !reflect.DeepEqual(before.Cards, after.Cards) {
+		change.Cards = copyCards(after.Cards)
+	}
+	for name, cards := range after.Piles {
+		if previous, ok := before.Piles[name]; !ok || !reflect.DeepEqual(previous, cards) {
+			if change.Piles == nil {
+				change.Piles = make(map[string][]models.Card)
+			}
+			change.Piles[name] = copyCards(cards)
+		}
+	}
+	for _, name := range sortedPileNames(before) {
+		if _, ok := after.Piles[name]; !ok {
+			change.RemovedPiles = append(change.RemovedPiles, name)
+		}
+	}
+	return change
+}
+
+// Applies the change of an event to a deck
+func applyChange(deck *models.Deck, change models.DeckChange) {
+	if change.Cards != nil {
+		deck.Cards = copyCards(change.Cards)
+	}
+	deck.Remaining = change.Remaining
+	deck.Shuffled = change.Shuffled
+	deck.Seed = change.Seed
+	for name, cards := range change.Piles {
+		if deck.Piles == nil {
+			deck.Piles = make(map[string][]models.Card)
+		}
+		deck.Piles[name] = copyCards(cards)
+	}
+	for _, name := range change.RemovedPiles {
+		delete(deck.Piles, name)
+	}
+	if len(deck.Piles) == 0 {
+		deck.Piles = nil
+	}
+}
+
+// Returns a history of at most MaxHistoryEvents events, merging the oldest events of a longer history into its
+// creation event, so that half of the allowed events are left. The events are not modified, merging creates a new
+// creation event, whose "compacted" detail counts the events it stands for.
+func compactHistory(history []models.DeckEvent) []models.DeckEvent {
+	if len(history) <= MaxHistoryEvents {
+		return history
+	}
+
+	// Undos may only revert events that are kept, the cut is moved forward until they do
+	cut := len(history) - MaxHistoryEvents/2 + 1
+	for cut < len(history) && !undosWithin(history[cut:]) {
+		cut++
+	}
+
+	// A history without its creation cannot rebuild the deck, so its oldest events are just dropped
+	if history[0].Type != EventCreate {
+		return append([]models.DeckEvent{}, history[cut:]...)
+	}
+
+	var replayed models.Deck
+	for _, i := range activeEvents(history[:cut]) {
+		applyChange(&replayed, history[i].Change)
+	}
+	merged := cut
+	details := map[string]string{}
+	for key, value := range history[0].Details {
+		details[key] = value
+	}
+	if previous, err := strconv.Atoi(details["compacted"]); err == nil {
+		merged += previous - 1
+	}
+	details["compacted"] = strconv.Itoa(merged)
+
+	creation := models.DeckEvent{
+		Type:    EventCreate,
+		Time:    history[0].Time,
+		Actor:   history[0].Actor,
+		Details: details,
+		Change:  changeOf(&models.Deck{}, &replayed),
+	}
+	return append([]models.DeckEvent{creation}, history[cut:]...)
+}
+
+// Tells if the undos of events only revert earlier events among them
+func undosWithin(events []models.DeckEvent) bool {
+	active := 0
+	for _, event := range events {
+		if event.Type != EventUndo {
+			active++
+			continue
+		}
+		count, _ := strconv.Atoi(event.Details["count"])
+		if count > active {
+			return false
+		}
+		active -= count
+	}
+	return true
+}
+
+// Returns card codes as a comma separated list, for the details of an event
+func codesDetail(codes [][2]rune) string {
+	result := make([]string, len(codes))
+	for i, code := range codes {
+		result[i] = string(code[:])
+	}
+	return strings.Join(result, ",")
+}
+
+// Returns the indexes of the events of a history that are in effect: all events but the undone ones
+// and the undo events themselves
+func activeEvents(history []models.DeckEvent) []int {
+	var active []int
+	for i, event := range history {
+		if event.Type != EventUndo {
+			active = append(active, i)
+			continue
+		}
+		count, _ := strconv.Atoi(event.Details["count"])
+		if count > len(active) {
+			count = len(active)
+		}
+		active = active[:len(active)-count]
+	}
+	return active
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// Default time between two evictions of expired decks
+const defaultEvictionInterval = time.Minute
+
+// Background goroutine periodically evicting expired decks from a repository
+type janitor struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Starts a background goroutine that evicts expired decks at the given interval, until Close is called.
+// Starting the janitor of a repository that already runs one has no effect.
+func (r *DecksInMemoryStore) StartJanitor(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultEvictionInterval
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.janitor != nil {
+		return
+	}
+
+	j := &janitor{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	r.janitor = j
+
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.EvictExpired()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stops the janitor, if one is running, and waits for it to finish
+func (r *DecksInMemoryStore) Close() error {
+	r.mu.RLock()
+	j := r.janitor
+	r.mu.RUnlock()
+	if j == nil {
+		return nil
+	}
+	j.stopOnce.Do(func() {
+		close(j.stop)
+	})
+	<-j.done
+	return nil
+}
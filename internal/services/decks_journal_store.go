@@ -0,0 +1,698 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnkjnk/decks-api/internal/models"
+)
+
+// The journal repository keeps all decks in memory, and persists every change of a deck as an entry appended
+// to a journal file. On startup, the decks are rebuilt by replaying the journal on top of the last snapshot.
+// Compaction writes a snapshot of all decks, then empties the journal.
+//
+// Both files are made of lines of JSON, each prefixed with the CRC-32 checksum of the JSON and a space. A crash
+// in the middle of appending an entry leaves a torn last line, which fails its checksum and is truncated on
+// startup. Snapshots are written to a temporary file which is renamed over the previous snapshot, so a snapshot
+// is always complete. Entries are numbered, and a snapshot starts with the number of the last entry it includes,
+// so that the entries of a journal that was not emptied after a snapshot are not replayed twice.
+//
+// Reading a deck updates its access time in memory only, the access time is persisted with the next change.
+
+const (
+	journalFileName  = "journal.log"    // Name of the journal file in the directory of the repository
+	snapshotFileName = "snapshot.jsonl" // Name of the snapshot file in the directory of the repository
+)
+
+// Operations of journal entries
+const (
+	journalSnapshot = "snapshot" // First line of a snapshot, with the number of the last entry it includes
+	journalPut      = "put"      // Stores a whole deck
+	journalUpdate   = "update"   // Applies the changes of an update to a deck
+	journalDelete   = "delete"   // Deletes a deck
+)
+
+// Default time between two compactions of the journal
+const defaultCompactionInterval = 5 * time.Minute
+
+// Journal implementation of decks repository, persisting decks in files of a directory
+type DecksJournalStore struct {
+	// Use a mutex for safe concurrent access to the decks and the journal
+	mu sync.Mutex
+	// All decks, rebuilt from the files on startup
+	decks map[uuid.UUID]*models.Deck
+	// Directory of the snapshot and journal files
+	dir string
+	// Journal file, opened for appending
+	journal *os.File
+	// Size of the journal file, up to the end of the last complete entry
+	size int64
+	// Number of the last entry
+	seq int64
+	// Source of creation and access timestamps
+	clock Clock
+	// Background compaction of the journal, see StartCompaction
+	compactor *janitor
+	// The journal is compacted and closed once, by the first call to Close, whose error is kept for later calls
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Entry of a journal, or line of a snapshot
+type journalEntry struct {
+	Seq    int64          `json:"seq"`
+	Op     string         `json:"op"`
+	Id     string         `json:"id,omitempty"`
+	Deck   *journalDeck   `json:"deck,omitempty"`   // The whole deck stored by a put
+	Update *journalChange `json:"update,omitempty"` // The changes of an update
+}
+
+// Deck as stored in a journal, with the encodings of the SQLite repository
+type journalDeck struct {
+	Shuffled     bool              `json:"shuffled"`
+	Remaining    int               `json:"remaining"`
+	Type         string            `json:"type"`
+	Seed         *int64            `json:"seed,omitempty"`
+	Fairness     string            `json:"fairness,omitempty"`
+	Hidden       bool              `json:"hidden"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Closed       bool              `json:"closed,omitempty"`
+	Cards        string            `json:"cards"`
+	CardDecks    string            `json:"card_decks,omitempty"`
+	Piles        string            `json:"piles"`
+	PileDecks    string            `json:"pile_decks"`
+	History      []sqliteEvent     `json:"history,omitempty"`
+	CreatedAt    int64             `json:"created_at"`
+	LastAccessed int64             `json:"last_accessed"`
+}
+
+// Changes of an update as stored in a journal
+type journalChange struct {
+	sqliteChange
+	Revealed     bool          `json:"revealed,omitempty"`
+	Closed       bool          `json:"closed,omitempty"`
+	Events       []sqliteEvent `json:"events,omitempty"`
+	LastAccessed int64         `json:"last_accessed"`
+}
+
+// Changes of an update of a deck, made of the change of its cards and piles, the events added to its history,
+// and its new access time
+type deckUpdate struct {
+	change       models.DeckChange
+	revealed     bool
+	closed       bool
+	events       []models.DeckEvent
+	lastAccessed time.Time
+}
+
+// Creates a new journal repository in the given directory, creating the directory if needed, and rebuilding
+// the decks from the files found in it
+func NewDecksJournalStore(dir string) (*DecksJournalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating journal directory %s: %w", dir, err)
+	}
+
+	r := &DecksJournalStore{
+		decks: make(map[uuid.UUID]*models.Deck),
+		dir:   dir,
+		clock: SystemClock,
+	}
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(filepath.Join(dir, journalFileName), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal in %s: %w", dir, err)
+	}
+	r.journal = journal
+	if err := r.replayJournal(); err != nil {
+		journal.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Starts a background goroutine that compacts the journal at the given interval, until Close is called.
+// Starting the compaction of a repository that already runs one has no effect.
+func (r *DecksJournalStore) StartCompaction(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultCompactionInterval
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.compactor != nil {
+		return
+	}
+
+	j := &janitor{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	r.compactor = j
+
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// A failed compaction leaves the journal as it was, and is retried at the next tick
+				r.Compact()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stops the compaction, if it is running, compacts the journal a last time and closes it. Closing the
+// repository again has no effect, and returns the error of the first call.
+func (r *DecksJournalStore) Close() error {
+	r.closeOnce.Do(func() {
+		r.mu.Lock()
+		j := r.compactor
+		r.mu.Unlock()
+		if j != nil {
+			j.stopOnce.Do(func() {
+				close(j.stop)
+			})
+			<-j.done
+		}
+
+		r.closeErr = r.Compact()
+		if err := r.journal.Close(); r.closeErr == nil {
+			r.closeErr = err
+		}
+	})
+	return r.closeErr
+}
+
+// Writes a snapshot of all decks, and empties the journal
+func (r *DecksJournalStore) Compact() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.writeSnapshot(); err != nil {
+		return err
+	}
+	// The snapshot includes every entry, so a crash before the journal is emptied loses nothing
+	if err := r.journal.Truncate(0); err != nil {
+		return fmt.Errorf("error emptying journal in %s: %w", r.dir, err)
+	}
+	r.size = 0
+	return nil
+}
+
+// Gets a deck
+func (r *DecksJournalStore) Get(id uuid.UUID) (*models.Deck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deck, ok := r.decks[id]
+	if !ok {
+		return nil, notFoundError(id)
+	}
+	deck.LastAccessed = r.now()
+	return copyDeck(deck), nil
+}
+
+// Puts a deck
+func (r *DecksJournalStore) Put(data *models.Deck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deck := copyDeck(data)
+	deck.LastAccessed = r.now()
+	if err := r.appendPut(deck); err != nil {
+		return err
+	}
+	r.decks[deck.DeckId] = deck
+	return nil
+}
+
+// Creates a deck
+func (r *DecksJournalStore) Create(data *models.Deck) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deck := copyDeck(data)
+	deck.DeckId = uuid.New()
+	deck.CreatedAt = r.now()
+	deck.LastAccessed = deck.CreatedAt
+	if err := r.appendPut(deck); err != nil {
+		return uuid.Nil, err
+	}
+	r.decks[deck.DeckId] = deck
+	data.DeckId = deck.DeckId
+	data.CreatedAt = deck.CreatedAt
+	data.LastAccessed = deck.LastAccessed
+	return deck.DeckId, nil
+}
+
+// Deletes a deck
+func (r *DecksJournalStore) Delete(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.decks[id]; !ok {
+		return notFoundError(id)
+	}
+	if err := r.append(journalEntry{Op: journalDelete, Id: id.String()}); err != nil {
+		return err
+	}
+	delete(r.decks, id)
+	return nil
+}
+
+// Updates a deck
+func (r *DecksJournalStore) Update(id uuid.UUID, update func(*models.Deck) error) (*models.Deck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.decks[id]
+	if !ok {
+		return nil, notFoundError(id)
+	}
+	// The update function works on a copy, so a failed update leaves the stored deck untouched
+	deck := copyDeck(stored)
+	deck.LastAccessed = r.now()
+	if err := update(deck); err != nil {
+		return nil, err
+	}
+
+	// Updates are journaled as their changes, unless they changed more than the changes can hold
+	if changes, ok := deckUpdateOf(stored, deck); ok {
+		encoded, err := encodeDeckUpdate(changes)
+		if err != nil {
+			return nil, fmt.Errorf("error storing deck %s: %w", id, err)
+		}
+		if err := r.append(journalEntry{Op: journalUpdate, Id: id.String(), Update: encoded}); err != nil {
+			return nil, err
+		}
+	} else if err := r.appendPut(deck); err != nil {
+		return nil, err
+	}
+
+	r.decks[id] = deck
+	return copyDeck(deck), nil
+}
+
+// Visits all decks
+func (r *DecksJournalStore) Each(visit func(*models.Deck) error) error {
+	r.mu.Lock()
+	ids := sortedIds(r.decks)
+	r.mu.Unlock()
+	// The lock is not held while visiting, so the visit function can use the repository
+	for _, id := range ids {
+		r.mu.Lock()
+		deck, ok := r.decks[id]
+		if ok {
+			deck = copyDeck(deck)
+		}
+		r.mu.Unlock()
+		if !ok {
+			continue
+		}
+		if err := visit(deck); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lists decks
+func (r *DecksJournalStore) List(filter DecksFilter, after uuid.UUID, limit int) (*DecksPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return listDecks(sortedIds(r.decks), after, limit, func(id uuid.UUID) *models.Deck {
+		deck := r.decks[id]
+		if !filter.matches(deck) {
+			return nil
+		}
+		return copyDeck(deck)
+	}), nil
+}
+
+// Returns the current time in UTC, the same way timestamps are read back from the files
+func (r *DecksJournalStore) now() time.Time {
+	return r.clock.Now().UTC()
+}
+
+// Appends an entry storing a whole deck to the journal
+func (r *DecksJournalStore) appendPut(deck *models.Deck) error {
+	encoded, err := encodeJournalDeck(deck)
+	if err != nil {
+		return fmt.Errorf("error storing deck %s: %w", deck.DeckId, err)
+	}
+	return r.append(journalEntry{Op: journalPut, Id: deck.DeckId.String(), Deck: encoded})
+}
+
+// Appends an entry to the journal, and waits until it is written to disk. The caller must hold the lock.
+func (r *DecksJournalStore) append(entry journalEntry) error {
+	entry.Seq = r.seq + 1
+	line, err := encodeJournalLine(entry)
+	if err != nil {
+		return fmt.Errorf("error storing deck %s: %w", entry.Id, err)
+	}
+	if _, err := r.journal.Write(line); err != nil {
+		// A partly written entry would hide all entries after it, so it is removed
+		r.journal.Truncate(r.size)
+		return fmt.Errorf("error storing deck %s: %w", entry.Id, err)
+	}
+	if err := r.journal.Sync(); err != nil {
+		r.journal.Truncate(r.size)
+		return fmt.Errorf("error storing deck %s: %w", entry.Id, err)
+	}
+	r.size += int64(len(line))
+	r.seq = entry.Seq
+	return nil
+}
+
+// Loads the decks of the snapshot, if there is one
+func (r *DecksJournalStore) loadSnapshot() error {
+	file, err := os.Open(filepath.Join(r.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening snapshot in %s: %w", r.dir, err)
+	}
+	defer file.Close()
+
+	// Snapshots are renamed into place once complete, so any invalid line is a corruption, not a torn write
+	reader := bufio.NewReader(file)
+	for first := true; ; first = false {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("error reading snapshot in %s: %w", r.dir, err)
+		}
+		entry, err := decodeJournalLine(line)
+		if err != nil {
+			return fmt.Errorf("error reading snapshot in %s: %w", r.dir, err)
+		}
+		if first != (entry.Op == journalSnapshot) || (!first && entry.Op != journalPut) {
+			return fmt.Errorf("error reading snapshot in %s: unexpected entry %s", r.dir, entry.Op)
+		}
+		if first {
+			r.seq = entry.Seq
+			continue
+		}
+		if err := r.apply(entry); err != nil {
+			return fmt.Errorf("error reading snapshot in %s: %w", r.dir, err)
+		}
+	}
+}
+
+// Applies the entries of the journal that are not included in the snapshot, and truncates the torn
+// entry left by a crash, if there is one. Entries are written whole with their line ending, so only the
+// last line can be torn: any other invalid line is a corruption, and the journal is left as it is.
+func (r *DecksJournalStore) replayJournal() error {
+	reader := bufio.NewReader(r.journal)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("error reading journal in %s: %w", r.dir, err)
+		}
+		if err == io.EOF {
+			// The last entry, without its line ending, was torn by a crash and is cut off
+			if err := r.journal.Truncate(r.size); err != nil {
+				return fmt.Errorf("error truncating journal in %s: %w", r.dir, err)
+			}
+			return nil
+		}
+		entry, err := decodeJournalLine(line)
+		if err != nil {
+			return fmt.Errorf("error reading journal in %s at offset %d: %w", r.dir, r.size, err)
+		}
+		if entry.Seq > r.seq {
+			if err := r.apply(entry); err != nil {
+				return fmt.Errorf("error replaying journal in %s: %w", r.dir, err)
+			}
+			r.seq = entry.Seq
+		}
+		r.size += int64(len(line))
+	}
+}
+
+// Applies an entry of the journal or a snapshot to the decks
+func (r *DecksJournalStore) apply(entry journalEntry) error {
+	id, err := uuid.Parse(entry.Id)
+	if err != nil {
+		return fmt.Errorf("invalid deck id in entry %d: %s", entry.Seq, entry.Id)
+	}
+	switch {
+	case entry.Op == journalPut && entry.Deck != nil:
+		deck, err := decodeJournalDeck(id, entry.Deck)
+		if err != nil {
+			return fmt.Errorf("invalid deck in entry %d: %w", entry.Seq, err)
+		}
+		r.decks[id] = deck
+	case entry.Op == journalUpdate && entry.Update != nil:
+		deck, ok := r.decks[id]
+		if !ok {
+			return fmt.Errorf("update of unknown deck %s in entry %d", id, entry.Seq)
+		}
+		changes, err := decodeDeckUpdate(entry.Update)
+		if err != nil {
+			return fmt.Errorf("invalid update in entry %d: %w", entry.Seq, err)
+		}
+		applyDeckUpdate(deck, changes)
+	case entry.Op == journalDelete:
+		delete(r.decks, id)
+	default:
+		return fmt.Errorf("invalid entry %d: %s", entry.Seq, entry.Op)
+	}
+	return nil
+}
+
+// Writes a snapshot of all decks, in the order of their ids, replacing the previous snapshot.
+// The caller must hold the lock.
+func (r *DecksJournalStore) writeSnapshot() error {
+	path := filepath.Join(r.dir, snapshotFileName)
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return fmt.Errorf("error writing snapshot in %s: %w", r.dir, err)
+	}
+	defer file.Close()
+
+	ids := sortedIds(r.decks)
+
+	writer := bufio.NewWriter(file)
+	entries := []journalEntry{{Seq: r.seq, Op: journalSnapshot}}
+	for _, id := range ids {
+		encoded, err := encodeJournalDeck(r.decks[id])
+		if err != nil {
+			return fmt.Errorf("error writing snapshot in %s: %w", r.dir, err)
+		}
+		entries = append(entries, journalEntry{Seq: r.seq, Op: journalPut, Id: id.String(), Deck: encoded})
+	}
+	for _, entry := range entries {
+		line, err := encodeJournalLine(entry)
+		if err != nil {
+			return fmt.Errorf("error writing snapshot in %s: %w", r.dir, err)
+		}
+		if _, err := writer.Write(line); err != nil {
+			return fmt.Errorf("error writing snapshot in %s: %w", r.dir, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing snapshot in %s: %w", r.dir, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("error writing snapshot in %s: %w", r.dir, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing snapshot in %s: %w", r.dir, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("error writing snapshot in %s: %w", r.dir, err)
+	}
+	// The rename itself is only durable once the directory is written to disk
+	if dir, err := os.Open(r.dir); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// Encodes an entry as a line of JSON, prefixed with its checksum
+func encodeJournalLine(entry journalEntry) ([]byte, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(data), data)), nil
+}
+
+// Decodes a line encoded by encodeJournalLine, checking its checksum
+func decodeJournalLine(line []byte) (journalEntry, error) {
+	var entry journalEntry
+	checksum, data, ok := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+	if !ok {
+		return entry, fmt.Errorf("invalid line: %q", line)
+	}
+	expected, err := strconv.ParseUint(string(checksum), 16, 32)
+	if err != nil || uint32(expected) != crc32.ChecksumIEEE(data) {
+		return entry, fmt.Errorf("invalid checksum of line: %q", line)
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// Encodes a deck with the encodings of the SQLite repository
+func encodeJournalDeck(deck *models.Deck) (*journalDeck, error) {
+	cards, cardDecks := encodeCards(deck.Cards)
+	piles, pileDecks, err := encodePiles(deck.Piles)
+	if err != nil {
+		return nil, err
+	}
+	fairness, err := encodeFairness(deck.Fairness)
+	if err != nil {
+		return nil, err
+	}
+	history, err := encodeEvents(deck.History)
+	if err != nil {
+		return nil, err
+	}
+	return &journalDeck{
+		Shuffled:     deck.Shuffled,
+		Remaining:    deck.Remaining,
+		Type:         deck.Type,
+		Seed:         deck.Seed,
+		Fairness:     fairness,
+		Hidden:       deck.Hidden,
+		Labels:       deck.Labels,
+		Closed:       deck.Closed,
+		Cards:        cards,
+		CardDecks:    cardDecks,
+		Piles:        piles,
+		PileDecks:    pileDecks,
+		History:      history,
+		CreatedAt:    encodeTime(deck.CreatedAt),
+		LastAccessed: encodeTime(deck.LastAccessed),
+	}, nil
+}
+
+// Decodes a deck encoded by encodeJournalDeck
+func decodeJournalDeck(id uuid.UUID, encoded *journalDeck) (*models.Deck, error) {
+	deck := models.Deck{
+		DeckId:       id,
+		Shuffled:     encoded.Shuffled,
+		Remaining:    encoded.Remaining,
+		Type:         encoded.Type,
+		Seed:         encoded.Seed,
+		Hidden:       encoded.Hidden,
+		Labels:       encoded.Labels,
+		Closed:       encoded.Closed,
+		CreatedAt:    decodeTime(encoded.CreatedAt),
+		LastAccessed: decodeTime(encoded.LastAccessed),
+	}
+	var err error
+	deck.Cards, err = decodeCards(encoded.Cards, encoded.CardDecks)
+	if err != nil {
+		return nil, err
+	}
+	deck.Piles, err = decodePiles(encoded.Piles, encoded.PileDecks)
+	if err != nil {
+		return nil, err
+	}
+	deck.Fairness, err = decodeFairness(encoded.Fairness)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded.History) > 0 {
+		deck.History, err = decodeEvents(encoded.History)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &deck, nil
+}
+
+// Returns the changes of an update from one state of a deck to another, and false if the update changed
+// more than the changes hold, such as its type or an earlier event of its history
+func deckUpdateOf(before *models.Deck, after *models.Deck) (deckUpdate, bool) {
+	if len(after.History) < len(before.History) {
+		return deckUpdate{}, false
+	}
+	changes := deckUpdate{
+		change:       changeOf(before, after),
+		revealed:     before.Fairness != nil && after.Fairness != nil && !before.Fairness.Revealed && after.Fairness.Revealed,
+		closed:       !before.Closed && after.Closed,
+		events:       after.History[len(before.History):],
+		lastAccessed: after.LastAccessed,
+	}
+	replayed := copyDeck(before)
+	applyDeckUpdate(replayed, changes)
+	return changes, reflect.DeepEqual(replayed, after)
+}
+
+// Applies the changes of an update to a deck
+func applyDeckUpdate(deck *models.Deck, changes deckUpdate) {
+	applyChange(deck, changes.change)
+	if changes.revealed && deck.Fairness != nil {
+		deck.Fairness.Revealed = true
+	}
+	if changes.closed {
+		deck.Closed = true
+	}
+	deck.History = append(deck.History, changes.events...)
+	deck.LastAccessed = changes.lastAccessed
+}
+
+// Encodes the changes of an update
+func encodeDeckUpdate(changes deckUpdate) (*journalChange, error) {
+	change, err := encodeChange(changes.change)
+	if err != nil {
+		return nil, err
+	}
+	events, err := encodeEvents(changes.events)
+	if err != nil {
+		return nil, err
+	}
+	return &journalChange{
+		sqliteChange: change,
+		Revealed:     changes.revealed,
+		Closed:       changes.closed,
+		Events:       events,
+		LastAccessed: encodeTime(changes.lastAccessed),
+	}, nil
+}
+
+// Decodes the changes of an update encoded by encodeDeckUpdate
+func decodeDeckUpdate(encoded *journalChange) (deckUpdate, error) {
+	change, err := decodeChange(encoded.sqliteChange)
+	if err != nil {
+		return deckUpdate{}, err
+	}
+	events, err := decodeEvents(encoded.Events)
+	if err != nil {
+		return deckUpdate{}, err
+	}
+	return deckUpdate{
+		change:       change,
+		revealed:     encoded.Revealed,
+		closed:       encoded.Closed,
+		events:       events,
+		lastAccessed: decodeTime(encoded.LastAccessed),
+	}, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Decks are released either by deleting them, which removes them with their history, or by closing them.
+// A closed deck can no longer be changed, but it can still be read: opened, peeked at, verified, listed and
+// its history read. Closing a provably fair deck reveals its server seed, as no more cards can be drawn.
+
+// Deletes a deck
+func (ds *DecksService) DeleteDeck(deckId string) error {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return err
+	}
+
+	return ds.decks.Delete(id)
+}
+
+// Closes a deck, rejecting any further change
+func (ds *DecksService) CloseDeck(deckId string) (*dto.CloseDeckResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	deck, err := ds.updateDeck(id, EventClose, nil, func(deck *models.Deck) error {
+		deck.Closed = true
+		if deck.Fairness != nil {
+			deck.Fairness.Revealed = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.CloseDeckResponse{
+		DeckId:    deck.DeckId.String(),
+		Remaining: deck.Remaining,
+		Closed:    deck.Closed,
+		Fairness:  fairnessDtoFromDeck(deck),
+	}
+
+	return &result, nil
+}
+
+// Returns the error for a change of a closed deck
+func closedError(deck *models.Deck) error {
+	return newError(ErrDeckClosed, "id", "deck id %s is closed", deck.DeckId)
+}
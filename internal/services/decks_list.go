@@ -0,0 +1,119 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Number of decks listed in a page, when no limit is given
+const DefaultListLimit = 20
+
+// Maximum number of decks listed in a page
+const MaxListLimit = 100
+
+// Maximum number of labels of a deck
+const MaxLabels = 20
+
+// Label keys and values are made of letters, digits, dashes, underscores and dots, so they can be given in
+// request parameters without escaping. Keys are not empty, and neither is longer than 63 characters.
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,63}$`)
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{0,63}$`)
+)
+
+// Lists the decks matching the filter, a page at a time. Each page is given the cursor returned with the
+// previous page, the first page is given an empty cursor. Listing decks does not update their access time.
+func (ds *DecksService) ListDecks(filter DecksFilter, cursor string, limit int) (*dto.ListDecksResponse, error) {
+
+	after := uuid.Nil
+	if cursor != "" {
+		var err error
+		after, err = uuid.Parse(cursor)
+		if err != nil {
+			return nil, newError(ErrInvalidParameter, "cursor", "invalid cursor: %s", cursor)
+		}
+	}
+
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	if limit < 0 || limit > MaxListLimit {
+		return nil, newError(ErrInvalidParameter, "limit", "limit must be between 1 and %d, got: %d", MaxListLimit, limit)
+	}
+	if filter.MinRemaining != nil && filter.MaxRemaining != nil && *filter.MinRemaining > *filter.MaxRemaining {
+		return nil, newError(ErrInvalidParameter, "max_remaining", "maximum remaining cards %d is less than the minimum %d", *filter.MaxRemaining, *filter.MinRemaining)
+	}
+
+	page, err := ds.decks.List(filter, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	decks := make([]dto.DeckSummaryDto, len(page.Decks))
+	for i, deck := range page.Decks {
+		decks[i] = ds.deckSummaryOf(deck)
+	}
+
+	result := dto.ListDecksResponse{
+		Decks: decks,
+	}
+	if page.Next != uuid.Nil {
+		result.NextCursor = page.Next.String()
+	}
+
+	return &result, nil
+}
+
+// Returns the summary of a deck, without its cards
+func (ds *DecksService) deckSummaryOf(deck *models.Deck) dto.DeckSummaryDto {
+	return dto.DeckSummaryDto{
+		DeckId:       deck.DeckId.String(),
+		Shuffled:     deck.Shuffled,
+		Remaining:    deck.Remaining,
+		Drawn:        len(deck.Cards) - deck.Remaining,
+		Type:         ds.deckTypeOf(deck).name,
+		Seed:         deck.Seed,
+		Fairness:     fairnessDtoFromDeck(deck),
+		Hidden:       deck.Hidden,
+		Labels:       deck.Labels,
+		Piles:        pileSizes(deck),
+		Closed:       deck.Closed,
+		CreatedAt:    deck.CreatedAt,
+		LastAccessed: deck.LastAccessed,
+	}
+}
+
+// Checks the number of labels, and the format of their keys and values
+func validateLabels(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return newError(ErrInvalidParameter, "labels", "a deck can have up to %d labels, got: %d", MaxLabels, len(labels))
+	}
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return newError(ErrInvalidParameter, "labels", "invalid label key: %s", key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return newError(ErrInvalidParameter, "labels", "invalid value of label %s: %s", key, value)
+		}
+	}
+	return nil
+}
+
+// Returns labels as a comma separated list of key:value pairs sorted by key, for the details of an event
+func labelsDetail(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]string, len(keys))
+	for i, key := range keys {
+		result[i] = key + ":" + labels[key]
+	}
+	return strings.Join(result, ",")
+}
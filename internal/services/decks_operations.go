@@ -0,0 +1,199 @@
+package services
+
+import (
+	"math/bits"
+	"strconv"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Operations are physical-style ways of rearranging the remaining cards of a deck. Drawn cards and
+// piles are never affected. Random operations take their randomness from the random source of the
+// service, and are never seeded.
+const (
+	OperationCut      = "cut"      // Moves the top cards to the bottom, at a given or random position
+	OperationFaroOut  = "faro_out" // Perfect riffle shuffle that keeps the top card on top
+	OperationFaroIn   = "faro_in"  // Perfect riffle shuffle that moves the top card to second place
+	OperationRiffle   = "riffle"   // Gilbert-Shannon-Reeds riffle shuffle
+	OperationOverhand = "overhand" // Overhand shuffle, moving packets of cards from the top one by one
+)
+
+// Names of all operations
+var Operations = []string{OperationCut, OperationFaroOut, OperationFaroIn, OperationRiffle, OperationOverhand}
+
+// Maximum number of times an operation is applied in one request
+const MaxOperationTimes = 100
+
+// Options for applying an operation to a deck
+type OperationOptions struct {
+	Operation string // The operation to apply
+	Position  *int   // Number of cards moved from the top to the bottom by a cut, random if nil
+	Times     int    // Number of times the operation is applied, once if 0
+}
+
+// Applies an operation to the remaining cards of a deck, and records it in the deck's history
+func (ds *DecksService) ApplyOperation(deckId string, options OperationOptions) (*dto.DeckOperationResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch options.Operation {
+	case OperationCut, OperationFaroOut, OperationFaroIn, OperationRiffle, OperationOverhand:
+	default:
+		return nil, newError(ErrInvalidParameter, "operation", "invalid operation: %s", options.Operation)
+	}
+	if options.Position != nil && options.Operation != OperationCut {
+		return nil, newError(ErrInvalidParameter, "position", "a position can only be used to cut a deck")
+	}
+
+	times := options.Times
+	if times == 0 {
+		times = 1
+	}
+	if times < 0 || times > MaxOperationTimes {
+		return nil, newError(ErrInvalidParameter, "times", "times must be between 1 and %d, got: %d", MaxOperationTimes, options.Times)
+	}
+
+	details := map[string]string{"times": strconv.Itoa(times)}
+	if options.Position != nil {
+		details["position"] = strconv.Itoa(*options.Position)
+	}
+
+	deck, err := ds.updateDeck(id, options.Operation, details, func(deck *models.Deck) error {
+		if err := checkFairOrder(deckId, deck); err != nil {
+			return err
+		}
+		skip := len(deck.Cards) - deck.Remaining
+		remaining := deck.Cards[skip:]
+
+		if options.Position != nil && (*options.Position < 1 || *options.Position >= len(remaining)) {
+			return newError(ErrInvalidParameter, "position", "cut position must be between 1 and %d, got: %d", len(remaining)-1, *options.Position)
+		}
+
+		for i := 0; i < times; i++ {
+			switch options.Operation {
+			case OperationCut:
+				position := 0
+				if options.Position != nil {
+					position = *options.Position
+				} else if len(remaining) > 1 {
+					position = 1 + randomIntn(ds.random, len(remaining)-1)
+				}
+				remaining = cutCards(remaining, position)
+			case OperationFaroOut:
+				remaining = faroShuffle(remaining, false)
+			case OperationFaroIn:
+				remaining = faroShuffle(remaining, true)
+			case OperationRiffle:
+				remaining = riffleShuffle(remaining, ds.random)
+			case OperationOverhand:
+				remaining = overhandShuffle(remaining, ds.random)
+			}
+		}
+
+		deck.Cards = append(copyCards(deck.Cards[:skip]), remaining...)
+		// After a random operation, the order can no longer be reproduced from the seed of the last shuffle
+		if options.Operation == OperationRiffle || options.Operation == OperationOverhand {
+			deck.Shuffled = true
+			deck.Seed = nil
+		} else if options.Operation == OperationCut && options.Position == nil {
+			deck.Seed = nil
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.DeckOperationResponse{
+		DeckId:    deck.DeckId.String(),
+		Shuffled:  deck.Shuffled,
+		Remaining: deck.Remaining,
+		Operation: options.Operation,
+		Times:     times,
+	}
+
+	return &result, nil
+}
+
+// Returns a new slice with the given number of cards moved from the top to the bottom
+func cutCards(cards []models.Card, position int) []models.Card {
+	return append(copyCards(cards[position:]), cards[:position]...)
+}
+
+// Returns a new slice with the cards split into two halves, which are perfectly interleaved. An out
+// shuffle starts with the top half, keeping the top card on top, an in shuffle starts with the bottom
+// half. With an odd number of cards, the half that starts has one card more.
+func faroShuffle(cards []models.Card, in bool) []models.Card {
+	half := (len(cards) + 1) / 2
+	if in {
+		half = len(cards) / 2
+	}
+	first, second := cards[:half], cards[half:]
+	if in {
+		first, second = second, first
+	}
+	result := make([]models.Card, 0, len(cards))
+	for i := range first {
+		result = append(result, first[i])
+		if i < len(second) {
+			result = append(result, second[i])
+		}
+	}
+	return result
+}
+
+// Returns a new slice with the cards riffle shuffled following the Gilbert-Shannon-Reeds model: the cards
+// are cut into two packets, with a binomially distributed number of cards in the top packet, then cards
+// drop from the bottom of either packet with a probability proportional to the size of the packet.
+func riffleShuffle(cards []models.Card, random RandomSource) []models.Card {
+	// The number of heads of a fair coin tossed once per card
+	cut := 0
+	for tossed := 0; tossed < len(cards); tossed += 64 {
+		coins := random.Uint64()
+		if left := len(cards) - tossed; left < 64 {
+			coins &= 1<<left - 1
+		}
+		cut += bits.OnesCount64(coins)
+	}
+
+	// Interleaving the packets top down is the same as dropping cards from their bottoms
+	top, bottom := cards[:cut], cards[cut:]
+	result := make([]models.Card, 0, len(cards))
+	for len(top) > 0 && len(bottom) > 0 {
+		if randomIntn(random, len(top)+len(bottom)) < len(top) {
+			result = append(result, top[0])
+			top = top[1:]
+		} else {
+			result = append(result, bottom[0])
+			bottom = bottom[1:]
+		}
+	}
+	result = append(result, top...)
+	return append(result, bottom...)
+}
+
+// Returns a new slice with the cards overhand shuffled: packets of random sizes, of up to a quarter of
+// the cards, are taken from the top one after the other, each one put on top of the previous ones.
+func overhandShuffle(cards []models.Card, random RandomSource) []models.Card {
+	maxPacket := len(cards) / 4
+	if maxPacket < 1 {
+		maxPacket = 1
+	}
+	result := make([]models.Card, len(cards))
+	end := len(cards)
+	for start := 0; start < len(cards); {
+		size := 1 + randomIntn(random, maxPacket)
+		if size > len(cards)-start {
+			size = len(cards) - start
+		}
+		copy(result[end-size:end], cards[start:start+size])
+		start += size
+		end -= size
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Returns the given number of cards from the top or the bottom of the remaining cards of a deck, without drawing
+// them. Cards are returned in the order they would be drawn: from the bottom, the bottom card comes first.
+// Peeking is the only way to see the remaining cards of a hidden deck.
+func (ds *DecksService) PeekCards(deckId string, count int, position string) (*dto.PeekCardsResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	if count < 0 {
+		return nil, newError(ErrInvalidParameter, "count", "invalid number of cards to peek at: %d", count)
+	}
+
+	switch position {
+	case "":
+		position = PositionTop
+	case PositionTop, PositionBottom:
+	default:
+		return nil, newError(ErrInvalidParameter, "position", "invalid position: %s", position)
+	}
+
+	deck, err := ds.decks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if deck.Remaining < count {
+		return nil, newError(ErrInsufficientCards, "count", "%d card(s) requested, but deck id %s has only %d card(s) left", count, deckId, deck.Remaining)
+	}
+
+	remaining := deck.Cards[len(deck.Cards)-deck.Remaining:]
+
+	var peeked []models.Card
+	if position == PositionTop {
+		peeked = remaining[:count]
+	} else {
+		peeked, _ = takeCards(remaining, positionsInRange(len(remaining)-1, len(remaining)-1-count))
+	}
+
+	result := dto.PeekCardsResponse{
+		DeckId:    deck.DeckId.String(),
+		Remaining: deck.Remaining,
+		Cards:     ds.cardDtosFromIds(deck, peeked),
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Piles are named collections of cards taken out of a deck, such as player hands or a discard pile.
+// A card is either drawn, remaining in the deck, or in exactly one pile. Piles are stacks: cards are
+// added to and drawn from the top, and are kept and listed from bottom to top.
+
+// Adds cards to the top of a pile, creating the pile if it does not exist. Either the given cards are
+// moved from the drawn cards of the deck, or, if no cards are given, the given number of cards is drawn
+// from the top of the deck.
+func (ds *DecksService) AddToPile(deckId string, pile string, draw int, cards [][2]rune) (*dto.PileResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+	if len(cards) == 0 && draw < 0 {
+		return nil, newError(ErrInvalidParameter, "draw", "invalid number of cards to draw: %d", draw)
+	}
+
+	details := map[string]string{"pile": pile, "count": strconv.Itoa(draw)}
+	if len(cards) > 0 {
+		details = map[string]string{"pile": pile, "cards": codesDetail(cards)}
+	}
+
+	deck, err := ds.updateDeck(id, EventPileAdd, details, func(deck *models.Deck) error {
+		skip := len(deck.Cards) - deck.Remaining
+		drawn := copyCards(deck.Cards[:skip])
+		remaining := deck.Cards[skip:]
+
+		var added []models.Card
+		if len(cards) == 0 {
+			if deck.Remaining < draw {
+				return newError(ErrInsufficientCards, "draw", "%d card(s) requested, but deck id %s has only %d card(s) left", draw, deckId, deck.Remaining)
+			}
+			added = remaining[:draw]
+			remaining = remaining[draw:]
+		} else {
+			for _, card := range cards {
+				i := indexOf(card, drawn)
+				if i < 0 {
+					return newError(ErrCardNotDrawn, "cards", "card %s has not been drawn from deck id: %s", string(card[:]), deckId)
+				}
+				added = append(added, drawn[i])
+				drawn = append(drawn[:i], drawn[i+1:]...)
+			}
+		}
+
+		if deck.Piles == nil {
+			deck.Piles = make(map[string][]models.Card)
+		}
+		deck.Piles[pile] = append(deck.Piles[pile], added...)
+		deck.Cards = append(drawn, remaining...)
+		deck.Remaining = len(remaining)
+		revealIfExhausted(deck)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := ds.pileResponseFromDeck(deck, pile)
+
+	return &result, nil
+}
+
+// Lists the cards in a pile
+func (ds *DecksService) ListPile(deckId string, pile string) (*dto.PileResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	deck, err := ds.decks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := deck.Piles[pile]; !ok {
+		return nil, pileNotFoundError(deckId, pile)
+	}
+
+	result := ds.pileResponseFromDeck(deck, pile)
+
+	return &result, nil
+}
+
+// Draws cards from the top of a pile, the drawn cards can later be returned to the deck
+func (ds *DecksService) DrawFromPile(deckId string, pile string, draw int) (*dto.DrawCardsResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+	if draw < 0 {
+		return nil, newError(ErrInvalidParameter, "draw", "invalid number of cards to draw: %d", draw)
+	}
+
+	details := map[string]string{"pile": pile, "count": strconv.Itoa(draw)}
+
+	var drawnCards []models.Card
+
+	deck, err := ds.updateDeck(id, EventPileDraw, details, func(deck *models.Deck) error {
+		cards, ok := deck.Piles[pile]
+		if !ok {
+			return pileNotFoundError(deckId, pile)
+		}
+		if len(cards) < draw {
+			return newError(ErrInsufficientCards, "draw", "%d card(s) requested, but pile %s of deck id %s has only %d card(s) left", draw, pile, deckId, len(cards))
+		}
+
+		// Cards are taken off the top one by one, so the topmost card is drawn first
+		drawnCards = make([]models.Card, draw)
+		for i := range drawnCards {
+			drawnCards[i] = cards[len(cards)-1-i]
+		}
+		deck.Piles[pile] = cards[:len(cards)-draw]
+
+		// Drawn cards are kept just before the remaining ones, like cards drawn from the deck
+		skip := len(deck.Cards) - deck.Remaining
+		deck.Cards = append(append(copyCards(deck.Cards[:skip]), drawnCards...), deck.Cards[skip:]...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.DrawCardsResponse{
+		Cards: ds.cardDtosFromIds(deck, drawnCards),
+	}
+
+	return &result, nil
+}
+
+// Shuffles the cards in a pile, with the seed of the options if it is not nil
+func (ds *DecksService) ShufflePile(deckId string, pile string, options ShuffleOptions) (*dto.PileResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := options.Seed
+
+	details := map[string]string{"pile": pile}
+	if seed != nil {
+		details["seed"] = strconv.FormatInt(*seed, 10)
+	}
+
+	deck, err := ds.updateDeck(id, EventPileShuffle, details, func(deck *models.Deck) error {
+		cards, ok := deck.Piles[pile]
+		if !ok {
+			return pileNotFoundError(deckId, pile)
+		}
+		shuffleCards(cards, ds.shuffleSource(seed))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := ds.pileResponseFromDeck(deck, pile)
+
+	return &result, nil
+}
+
+// Returns all cards of a pile to the deck, at the given position, and removes the pile
+func (ds *DecksService) ReturnPile(deckId string, pile string, position string) (*dto.ReturnCardsResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err = parsePosition(position)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]string{"pile": pile, "position": position}
+
+	var returnedCards []models.Card
+
+	deck, err := ds.updateDeck(id, EventPileReturn, details, func(deck *models.Deck) error {
+		if err := checkFairOrder(deckId, deck); err != nil {
+			return err
+		}
+		cards, ok := deck.Piles[pile]
+		if !ok {
+			return pileNotFoundError(deckId, pile)
+		}
+		returnedCards = cards
+		delete(deck.Piles, pile)
+
+		skip := len(deck.Cards) - deck.Remaining
+		deck.Cards = append(copyCards(deck.Cards[:skip]), insertCards(deck.Cards[skip:], returnedCards, position, intnOf(ds.random))...)
+		deck.Remaining = deck.Remaining + len(returnedCards)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.ReturnCardsResponse{
+		DeckId:    deck.DeckId.String(),
+		Shuffled:  deck.Shuffled,
+		Remaining: deck.Remaining,
+		Cards:     ds.cardDtosFromIds(deck, returnedCards),
+	}
+
+	return &result, nil
+}
+
+// Returns the error for a pile missing from a deck
+func pileNotFoundError(deckId string, pile string) error {
+	return newError(ErrPileNotFound, "pile", "pile %s not found in deck id: %s", pile, deckId)
+}
+
+func (ds *DecksService) pileResponseFromDeck(deck *models.Deck, pile string) dto.PileResponse {
+	return dto.PileResponse{
+		DeckId:    deck.DeckId.String(),
+		Remaining: deck.Remaining,
+		Pile:      pile,
+		Cards:     ds.cardDtosFromIds(deck, deck.Piles[pile]),
+	}
+}
+
+// Returns the number of cards in each pile of a deck, or nil if the deck has no piles
+func pileSizes(deck *models.Deck) map[string]int {
+	if len(deck.Piles) == 0 {
+		return nil
+	}
+	result := make(map[string]int, len(deck.Piles))
+	for name, cards := range deck.Piles {
+		result[name] = len(cards)
+	}
+	return result
+}
+
+// Returns the names of the piles of a deck in alphabetical order
+func sortedPileNames(deck *models.Deck) []string {
+	names := make([]string, 0, len(deck.Piles))
+	for name := range deck.Piles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
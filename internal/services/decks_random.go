@@ -0,0 +1,113 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math/bits"
+	"sync"
+
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+)
+
+// Sources of random numbers selectable in the configuration
+const (
+	RandomSourceCrypto = "crypto" // Cryptographically secure random numbers of the operating system
+	RandomSourceSeeded = "seeded" // Pseudo-random numbers from a fixed seed, for tests and simulations
+)
+
+// Source of the random numbers of shuffles and random positions
+type RandomSource interface {
+	Uint64() uint64 // Returns a uniformly distributed random 64-bit number
+}
+
+// Returns the source of random numbers selected in the configuration, cryptographically secure by default
+func NewRandomSource(config configs.RandomConfig) RandomSource {
+	switch config.Source {
+	case "", RandomSourceCrypto:
+		return CryptoRandom{}
+	case RandomSourceSeeded:
+		return NewSeededRandom(config.Seed)
+	default:
+		panic("Bad configuration, unknown random source: " + config.Source)
+	}
+}
+
+// Cryptographically secure source of random numbers, safe for concurrent use
+type CryptoRandom struct{}
+
+// Returns the next random 64-bit number
+func (CryptoRandom) Uint64() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// Reading from the operating system's random number generator only fails if it is broken
+		panic("error reading random numbers: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Pseudo-random source from a fixed seed, safe for concurrent use. The numbers are the same as those of seeded shuffles.
+type SeededRandom struct {
+	mu     sync.Mutex
+	random *seededRandom
+}
+
+// Creates a new pseudo-random source from a seed
+func NewSeededRandom(seed int64) *SeededRandom {
+	return &SeededRandom{random: newSeededRandom(seed)}
+}
+
+// Returns the next pseudo-random 64-bit number
+func (r *SeededRandom) Uint64() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.random.Uint64()
+}
+
+// Pseudo-random generator of seeded shuffles, based on SplitMix64.
+// A seed must produce the same order of the same cards in every version of the service, so that games can be
+// replayed. Neither this generator, nor the way shuffles use its output, may ever change.
+type seededRandom struct {
+	state uint64
+}
+
+func newSeededRandom(seed int64) *seededRandom {
+	return &seededRandom{state: uint64(seed)}
+}
+
+// Returns the next pseudo-random 64-bit number
+func (r *seededRandom) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Returns a random number in [0, n) from a source, without modulo bias
+func randomIntn(source RandomSource, n int) int {
+	// Lemire's multiply-and-shift, rejecting the few products that would favor some results
+	bound := uint64(n)
+	hi, lo := bits.Mul64(source.Uint64(), bound)
+	if lo < bound {
+		threshold := -bound % bound
+		for lo < threshold {
+			hi, lo = bits.Mul64(source.Uint64(), bound)
+		}
+	}
+	return int(hi)
+}
+
+// Returns a function returning random numbers in [0, n) from a source
+func intnOf(source RandomSource) func(int) int {
+	return func(n int) int {
+		return randomIntn(source, n)
+	}
+}
+
+// Returns the source of random positions of a shuffle, seeded if a seed is given
+func (ds *DecksService) shuffleSource(seed *int64) func(int) int {
+	if seed == nil {
+		return intnOf(ds.random)
+	}
+	return intnOf(newSeededRandom(*seed))
+}
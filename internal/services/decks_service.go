@@ -1,8 +1,7 @@
 package services
 
 import (
-	"fmt"
-	"math/rand"
+	"io"
 	"strconv"
 
 	"github.com/google/uuid"
@@ -13,55 +12,175 @@ import (
 
 // Decks service interface
 type DecksServicer interface {
-	CreateDeck(shuffle bool, cards [][2]rune) (*dto.CreateDeckResponse, error)
+	CreateDeck(options CreateDeckOptions) (*dto.CreateDeckResponse, error)
 	OpenDeck(deckId string) (*dto.OpenDeckResponse, error)
-	DrawCards(deckId string, draw uint8) (*dto.DrawCardsResponse, error)
+	DescribeDeck(deckId string) (*dto.DeckStateResponse, error)
+	DrawCards(deckId string, options DrawOptions) (*dto.DrawCardsResponse, error)
+	PeekCards(deckId string, count int, position string) (*dto.PeekCardsResponse, error)
+	ReturnCards(deckId string, options ReturnOptions) (*dto.ReturnCardsResponse, error)
+	ShuffleDeck(deckId string, options ShuffleOptions) (*dto.ShuffleDeckResponse, error)
+	ApplyOperation(deckId string, options OperationOptions) (*dto.DeckOperationResponse, error)
+	AddToPile(deckId string, pile string, draw int, cards [][2]rune) (*dto.PileResponse, error)
+	ListPile(deckId string, pile string) (*dto.PileResponse, error)
+	DrawFromPile(deckId string, pile string, draw int) (*dto.DrawCardsResponse, error)
+	ShufflePile(deckId string, pile string, options ShuffleOptions) (*dto.PileResponse, error)
+	VerifyDeck(deckId string) (*dto.VerifyDeckResponse, error)
+	Deal(deckId string, players []string, count int, burn int) (*dto.DealResponse, error)
+	ReturnPile(deckId string, pile string, position string) (*dto.ReturnCardsResponse, error)
+	ListDeckTypes() (*dto.DeckTypesResponse, error)
+	History(deckId string) (*dto.HistoryResponse, error)
+	Undo(deckId string, count int) (*dto.UndoResponse, error)
+	WithActor(actor string) DecksServicer
+	ExportDecks(w io.Writer, format string) (int, error)
+	ImportDecks(r io.Reader) (int, error)
+	ListDecks(filter DecksFilter, cursor string, limit int) (*dto.ListDecksResponse, error)
+	DeleteDeck(deckId string) error
+	CloseDeck(deckId string) (*dto.CloseDeckResponse, error)
+	CloneDeck(deckId string, options CloneOptions) (*dto.CloneDeckResponse, error)
+}
+
+// Positions in the remaining cards at which returned cards can be placed
+const (
+	PositionTop    = "top"    // Returned cards are drawn next
+	PositionBottom = "bottom" // Returned cards are drawn last
+	PositionRandom = "random" // Each returned card is placed at a random position
+)
+
+// Maximum number of decks in a shoe
+const MaxDeckCount = 100
+
+// Options for creating a deck
+type CreateDeckOptions struct {
+	Shuffle    bool              // If the deck is shuffled
+	Cards      [][2]rune         // Codes of the cards to use, all cards are used if empty
+	DeckCount  int               // Number of copies of the cards to build a shoe from, a single deck if 0 or 1
+	Type       string            // Name of the deck type, the default type if empty
+	Seed       *int64            // Seed of the shuffle, which always produces the same order of the same cards, random if nil
+	Fair       bool              // If the deck is shuffled provably fair, with a secret server seed and the client seed
+	ClientSeed string            // Seed supplied by the client for a provably fair shuffle
+	Hidden     bool              // If the remaining cards can only be seen by peeking, and not by opening the deck
+	Labels     map[string]string // Labels of the deck, to find it when listing decks
+	State      bool              // If the response holds the summary of the created deck
+	Strict     bool              // If cards that are not in the deck type are rejected, instead of being left out
+}
+
+// Options for returning drawn cards to a deck
+type ReturnOptions struct {
+	Cards    [][2]rune // Codes of the drawn cards to return, all drawn cards are returned if empty
+	Position string    // Where the cards are returned to: the top (default), the bottom, or random positions
+	State    bool      // If the response holds the summary of the deck after returning the cards
+}
+
+// Options for shuffling the cards of a deck or of a pile
+type ShuffleOptions struct {
+	Seed  *int64 // Seed of the shuffle, which always produces the same order of the same cards, random if nil
+	State bool   // If the response of shuffling a deck holds the summary of the deck after the shuffle
 }
 
 type DecksService struct {
-	suits     map[rune]string // Names of all possible suits
-	values    map[rune]string // Names of all possible card values
-	baseCards [][2]rune       // Codes of all possible cards
-	decks     DecksStorer     // Repository of decks
+	types       map[string]*deckType // All deck types by name
+	defaultType string               // Name of the type of decks created without a type
+	random      RandomSource         // Source of random numbers of unseeded shuffles and random positions
+	decks       DecksStorer          // Repository of decks
+	clock       Clock                // Source of the time of the events of decks
+	actor       string               // Who the events of decks are recorded for, empty if unknown
 }
 
 func NewDecksService(config configs.DecksConfig, store DecksStorer) DecksServicer {
-	// We initialize the maps of all suits and values with first character (code) as key,
-	// as well as all suit and value cudes
-	suits, suitCodes := dictionarize(config.Suits)
-	values, valueCodes := dictionarize(config.Values)
-	// now the array of all possible cards
-	cards := allCards(suitCodes, valueCodes)
+	return NewDecksServiceWithRandom(config, store, NewRandomSource(config.Random))
+}
+
+// Creates a decks service with the given source of random numbers, instead of the one of the configuration
+func NewDecksServiceWithRandom(config configs.DecksConfig, store DecksStorer, random RandomSource) DecksServicer {
+	// We initialize all deck types with their suits, values and cards
+	types, defaultType := deckTypesFromConfig(config)
 	// and finally, we initialize our decks service
 	newDecksService := DecksService{
-		suits:     suits,
-		values:    values,
-		baseCards: cards,
-		decks:     store,
+		types:       types,
+		defaultType: defaultType,
+		random:      random,
+		decks:       store,
+		clock:       SystemClock,
 	}
 
 	return &newDecksService
 }
 
-// Creates a new deck
-func (ds *DecksService) CreateDeck(shuffle bool, cards [][2]rune) (*dto.CreateDeckResponse, error) {
+// Creates a new deck, or a shoe of several decks
+func (ds *DecksService) CreateDeck(options CreateDeckOptions) (*dto.CreateDeckResponse, error) {
+
+	if options.DeckCount < 0 || options.DeckCount > MaxDeckCount {
+		return nil, newError(ErrInvalidParameter, "deck_count", "deck count must be between 1 and %d, or 0 for a single deck, got: %d", MaxDeckCount, options.DeckCount)
+	}
+
+	typeName := options.Type
+	if typeName == "" {
+		typeName = ds.defaultType
+	}
+	deckType, ok := ds.types[typeName]
+	if !ok {
+		return nil, newError(ErrInvalidParameter, "type", "unknown deck type: %s", typeName)
+	}
 
-	cards = intersect(ds.baseCards, cards)
+	if options.Strict {
+		for _, card := range options.Cards {
+			if !contains(card, deckType.baseCards) {
+				return nil, newError(ErrInvalidCardCode, "cards", "card %s is not in deck type %s", string(card[:]), typeName)
+			}
+		}
+	}
 
-	if len(cards) == 0 {
-		cards = copyCards(ds.baseCards)
+	codes := intersect(deckType.baseCards, options.Cards)
+
+	if len(codes) == 0 {
+		codes = deckType.baseCards
 	}
 
-	if shuffle {
-		shuffleCards(cards)
+	if options.Seed != nil && !options.Shuffle {
+		return nil, newError(ErrInvalidParameter, "seed", "a seed can only be used to shuffle a deck")
+	}
+	if options.Fair && (!options.Shuffle || options.Seed != nil) {
+		return nil, newError(ErrInvalidParameter, "fair", "a provably fair deck must be shuffled, without a seed")
+	}
+	if options.Fair && options.ClientSeed == "" {
+		return nil, newError(ErrInvalidParameter, "client_seed", "a provably fair deck requires a client seed")
+	}
+	if err := validateLabels(options.Labels); err != nil {
+		return nil, err
+	}
+
+	cards := buildShoe(codes, options.DeckCount)
+
+	var fairness *models.Fairness
+	if options.Fair {
+		var err error
+		cards, fairness, err = fairShuffle(cards, options.ClientSeed)
+		if err != nil {
+			return nil, err
+		}
+	} else if options.Shuffle {
+		shuffleCards(cards, ds.shuffleSource(options.Seed))
 	}
 
-	remaining := uint8(len(cards))
+	remaining := len(cards)
 
 	newDeck := models.Deck{
 		Cards:     cards,
-		Shuffled:  shuffle,
+		Shuffled:  options.Shuffle,
 		Remaining: remaining,
+		Type:      typeName,
+		Seed:      options.Seed,
+		Fairness:  fairness,
+		Hidden:    options.Hidden,
+	}
+	if len(options.Labels) > 0 {
+		newDeck.Labels = make(map[string]string, len(options.Labels))
+		for key, value := range options.Labels {
+			newDeck.Labels[key] = value
+		}
+	}
+	newDeck.History = []models.DeckEvent{
+		ds.newEvent(EventCreate, createDetails(typeName, options), changeOf(&models.Deck{}, &newDeck)),
 	}
 
 	_, err := ds.decks.Create(&newDeck)
@@ -71,6 +190,7 @@ func (ds *DecksService) CreateDeck(shuffle bool, cards [][2]rune) (*dto.CreateDe
 	}
 
 	result := createDeckResponseFromDeck(newDeck)
+	result.Deck = ds.stateOf(options.State, &newDeck)
 
 	return &result, nil
 }
@@ -78,71 +198,170 @@ func (ds *DecksService) CreateDeck(shuffle bool, cards [][2]rune) (*dto.CreateDe
 // Opens a deck
 func (ds *DecksService) OpenDeck(deckId string) (*dto.OpenDeckResponse, error) {
 
-	id, err := uuid.Parse(deckId)
+	id, err := parseDeckId(deckId)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing id: %s", deckId)
+		return nil, err
 	}
 
 	deck, err := ds.decks.Get(id)
 	if err != nil {
 		return nil, err
 	}
+	if deck.Hidden {
+		return nil, newError(ErrDeckHidden, "id", "the cards of deck id %s are hidden, they can only be peeked at", deckId)
+	}
 	if deck.Remaining == 0 {
-		return nil, fmt.Errorf("no cards remaining in deck id: %s", deckId)
+		return nil, newError(ErrEmptyDeck, "id", "no cards remaining in deck id: %s", deckId)
 	}
 
-	remainingCards := deck.Cards[len(deck.Cards)-int(deck.Remaining):]
+	remainingCards := deck.Cards[len(deck.Cards)-deck.Remaining:]
 
 	result := dto.OpenDeckResponse{
 		DeckId:    deck.DeckId.String(),
 		Shuffled:  deck.Shuffled,
 		Remaining: deck.Remaining,
-		Cards:     ds.cardDtosFromIds(remainingCards),
+		Type:      ds.deckTypeOf(deck).name,
+		Seed:      deck.Seed,
+		Fairness:  fairnessDtoFromDeck(deck),
+		Cards:     ds.cardDtosFromIds(deck, remainingCards),
+		Piles:     pileSizes(deck),
+		Closed:    deck.Closed,
 	}
 
 	return &result, nil
 }
 
-// Draws cards
-func (ds *DecksService) DrawCards(deckId string, draw uint8) (*dto.DrawCardsResponse, error) {
+// Returns drawn cards to a deck
+func (ds *DecksService) ReturnCards(deckId string, options ReturnOptions) (*dto.ReturnCardsResponse, error) {
 
-	id, err := uuid.Parse(deckId)
+	id, err := parseDeckId(deckId)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing id: %s", deckId)
+		return nil, err
 	}
 
-	deck, err := ds.decks.Get(id)
+	cards := options.Cards
+	position, err := parsePosition(options.Position)
 	if err != nil {
 		return nil, err
 	}
-	if deck.Remaining < draw {
-		return nil, fmt.Errorf("%s card(s) requested, but deck id %s has only %s card(s) left", strconv.Itoa(int(draw)), deckId, strconv.Itoa(int((deck.Remaining))))
+
+	details := map[string]string{"position": position}
+	if len(cards) > 0 {
+		details["cards"] = codesDetail(cards)
+	}
+
+	var returnedCards []models.Card
+
+	deck, err := ds.updateDeck(id, EventReturn, details, func(deck *models.Deck) error {
+		if err := checkFairOrder(deckId, deck); err != nil {
+			return err
+		}
+		skip := len(deck.Cards) - deck.Remaining
+		drawn := copyCards(deck.Cards[:skip])
+		remaining := deck.Cards[skip:]
+
+		// With no cards selected, all drawn cards are returned
+		if len(cards) == 0 {
+			returnedCards = drawn
+			drawn = nil
+		} else {
+			returnedCards = make([]models.Card, 0, len(cards))
+			for _, card := range cards {
+				i := indexOf(card, drawn)
+				if i < 0 {
+					return newError(ErrCardNotDrawn, "cards", "card %s has not been drawn from deck id: %s", string(card[:]), deckId)
+				}
+				returnedCards = append(returnedCards, drawn[i])
+				drawn = append(drawn[:i], drawn[i+1:]...)
+			}
+		}
+
+		deck.Cards = append(drawn, insertCards(remaining, returnedCards, position, intnOf(ds.random))...)
+		deck.Remaining = deck.Remaining + len(returnedCards)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.ReturnCardsResponse{
+		DeckId:    deck.DeckId.String(),
+		Shuffled:  deck.Shuffled,
+		Remaining: deck.Remaining,
+		Cards:     ds.cardDtosFromIds(deck, returnedCards),
+		Deck:      ds.stateOf(options.State, deck),
 	}
 
-	skip := len(deck.Cards) - int(deck.Remaining)
+	return &result, nil
+}
 
-	deck.Remaining = deck.Remaining - draw
+// Shuffles the remaining cards of a deck, with the seed of the options if it is not nil
+func (ds *DecksService) ShuffleDeck(deckId string, options ShuffleOptions) (*dto.ShuffleDeckResponse, error) {
 
-	err = ds.decks.Put(deck)
+	id, err := parseDeckId(deckId)
 	if err != nil {
 		return nil, err
 	}
 
-	drawnCards := deck.Cards[skip : skip+int(draw)]
+	seed := options.Seed
+	var details map[string]string
+	if seed != nil {
+		details = map[string]string{"seed": strconv.FormatInt(*seed, 10)}
+	}
 
-	result := dto.DrawCardsResponse{
-		Cards: ds.cardDtosFromIds(drawnCards),
+	deck, err := ds.updateDeck(id, EventShuffle, details, func(deck *models.Deck) error {
+		if err := checkFairOrder(deckId, deck); err != nil {
+			return err
+		}
+		// Drawn cards stay where they are, only the remaining ones are shuffled
+		shuffleCards(deck.Cards[len(deck.Cards)-deck.Remaining:], ds.shuffleSource(seed))
+		deck.Shuffled = true
+		deck.Seed = seed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := dto.ShuffleDeckResponse{
+		DeckId:    deck.DeckId.String(),
+		Shuffled:  deck.Shuffled,
+		Remaining: deck.Remaining,
+		Seed:      deck.Seed,
+		Deck:      ds.stateOf(options.State, deck),
 	}
 
 	return &result, nil
 }
 
+// Validates a position for returned cards, defaulting to the top
+func parsePosition(position string) (string, error) {
+	switch position {
+	case "":
+		return PositionTop, nil
+	case PositionTop, PositionBottom, PositionRandom:
+		return position, nil
+	default:
+		return "", newError(ErrInvalidParameter, "position", "invalid position: %s", position)
+	}
+}
+
+// Parses the id of a deck
+func parseDeckId(deckId string) (uuid.UUID, error) {
+	id, err := uuid.Parse(deckId)
+	if err != nil {
+		return uuid.Nil, newError(ErrInvalidId, "id", "error parsing id: %s", deckId)
+	}
+	return id, nil
+}
+
 // Function that returns all possible card codes for given arrays of suit and value codes
 func allCards(suits []rune, values []rune) [][2]rune {
 	// First we initialize an empty array of two-rune codes
 	result := make([][2]rune, len(suits)*len(values))
 	// Then we iterate over all suit and value codes to get all possible cards
-	var i uint8 = 0
+	i := 0
 	for _, s := range suits {
 		for _, v := range values {
 			result[i][0] = v
@@ -170,18 +389,57 @@ func dictionarize(input []string) (map[rune]string, []rune) {
 	return resultMap, resultKeys
 }
 
-// Shuffles a slice of cards
-func shuffleCards(cards [][2]rune) {
+// For the given extra cards, returns a map with the code of each card as the key, and an array of all codes.
+// The codes must be unique, and different from the codes of all cards of the grid of suits and values.
+func extraCards(input []configs.ExtraCardConfig, gridCards [][2]rune) (map[[2]rune]configs.ExtraCardConfig, [][2]rune) {
+	resultMap := make(map[[2]rune]configs.ExtraCardConfig, len(input))
+	resultKeys := make([][2]rune, len(input))
+	for i, card := range input {
+		runes := []rune(card.Code)
+		if len(runes) != 2 {
+			panic("Bad configuration, extra card codes must have two characters: " + card.Code)
+		}
+		code := [2]rune{runes[0], runes[1]}
+		if _, exists := resultMap[code]; exists || contains(code, gridCards) {
+			panic("Bad configuration, multiple cards have the same code: " + card.Code)
+		}
+		resultMap[code] = card
+		resultKeys[i] = code
+	}
+	return resultMap, resultKeys
+}
+
+// Builds the cards of a shoe from the given number of copies of the card codes, in order.
+// Cards of a shoe keep the number of the deck they come from, so that duplicates can be told apart.
+func buildShoe(codes [][2]rune, deckCount int) []models.Card {
+	if deckCount <= 1 {
+		result := make([]models.Card, len(codes))
+		for i, code := range codes {
+			result[i] = models.Card{Code: code}
+		}
+		return result
+	}
+	result := make([]models.Card, 0, deckCount*len(codes))
+	for deck := 1; deck <= deckCount; deck++ {
+		for _, code := range codes {
+			result = append(result, models.Card{Code: code, Deck: deck})
+		}
+	}
+	return result
+}
+
+// Shuffles a slice of cards, with random positions in [0, n) taken from intn
+func shuffleCards(cards []models.Card, intn func(n int) int) {
 	// Fisher-Yates shuffle
 	for i := len(cards) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
+		j := intn(i + 1)
 		cards[i], cards[j] = cards[j], cards[i]
 	}
 }
 
 // Copies a slice of cards
-func copyCards(input [][2]rune) [][2]rune {
-	output := make([][2]rune, len(input))
+func copyCards(input []models.Card) []models.Card {
+	output := make([]models.Card, len(input))
 	copy(output, input)
 	return output
 }
@@ -207,25 +465,74 @@ func contains(card [2]rune, cards [][2]rune) bool {
 	return false
 }
 
+// Returns the index of the first card with the given code in a slice, or -1 if it is not found
+func indexOf(code [2]rune, cards []models.Card) int {
+	for i, c := range cards {
+		if c.Code == code {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns a new slice with cards inserted at the top, at the bottom or at random positions of a slice,
+// with random positions in [0, n) taken from intn
+func insertCards(cards []models.Card, inserted []models.Card, position string, intn func(n int) int) []models.Card {
+	switch position {
+	case PositionTop:
+		return append(copyCards(inserted), cards...)
+	case PositionBottom:
+		return append(copyCards(cards), inserted...)
+	default:
+		result := copyCards(cards)
+		for _, card := range inserted {
+			i := intn(len(result) + 1)
+			result = append(result[:i], append([]models.Card{card}, result[i:]...)...)
+		}
+		return result
+	}
+}
+
+// Returns the details of the creation event of a deck
+func createDetails(typeName string, options CreateDeckOptions) map[string]string {
+	details := map[string]string{
+		"type":    typeName,
+		"shuffle": strconv.FormatBool(options.Shuffle),
+	}
+	if options.DeckCount > 1 {
+		details["deck_count"] = strconv.Itoa(options.DeckCount)
+	}
+	if options.Seed != nil {
+		details["seed"] = strconv.FormatInt(*options.Seed, 10)
+	}
+	if options.Fair {
+		details["fair"] = "true"
+	}
+	if options.Hidden {
+		details["hidden"] = "true"
+	}
+	if len(options.Labels) > 0 {
+		details["labels"] = labelsDetail(options.Labels)
+	}
+	return details
+}
+
 func createDeckResponseFromDeck(deck models.Deck) dto.CreateDeckResponse {
 	result := dto.CreateDeckResponse{
 		DeckId:    deck.DeckId.String(),
 		Shuffled:  deck.Shuffled,
 		Remaining: deck.Remaining,
+		Type:      deck.Type,
+		Seed:      deck.Seed,
+		Fairness:  fairnessDtoFromDeck(&deck),
+		Hidden:    deck.Hidden,
+		Labels:    deck.Labels,
 	}
 
 	return result
 }
 
-// Returns a slice of card DTOs from a slice of IDs
-func (ds *DecksService) cardDtosFromIds(cards [][2]rune) []dto.CardDto {
-	result := make([]dto.CardDto, len(cards))
-	for i, card := range cards {
-		result[i] = dto.CardDto{
-			Value: ds.values[card[0]],
-			Suit:  ds.suits[card[1]],
-			Code:  string(card[:]),
-		}
-	}
-	return result
+// Returns a slice of card DTOs from a slice of cards of a deck, named after the deck's type
+func (ds *DecksService) cardDtosFromIds(deck *models.Deck, cards []models.Card) []dto.CardDto {
+	return ds.deckTypeOf(deck).cardDtos(cards)
 }
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+)
+
+// A snapshot holds all decks of a repository, one JSON object per line, with the encodings of the SQLite
+// repository. Decks keep their ids, cards, piles, history and timestamps, so a snapshot can be imported into
+// any repository to move decks between hosts or to restore a backup.
+
+// Formats of snapshots
+const (
+	SnapshotFormatJsonl = "jsonl" // JSON Lines
+	SnapshotFormatGzip  = "gzip"  // JSON Lines compressed with gzip
+)
+
+// Deck as stored in a snapshot
+type snapshotDeck struct {
+	DeckId string `json:"deck_id"`
+	journalDeck
+}
+
+// Writes a snapshot of all decks of a repository in the given format, and returns the number of decks written
+func ExportSnapshot(store DecksStorer, w io.Writer, format string) (int, error) {
+	var compressed *gzip.Writer
+	switch format {
+	case "", SnapshotFormatJsonl:
+	case SnapshotFormatGzip:
+		compressed = gzip.NewWriter(w)
+		w = compressed
+	default:
+		return 0, newError(ErrInvalidParameter, "format", "invalid snapshot format: %s", format)
+	}
+
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	exported := 0
+	err := store.Each(func(deck *models.Deck) error {
+		encoded, err := encodeJournalDeck(deck)
+		if err != nil {
+			return fmt.Errorf("error exporting deck %s: %w", deck.DeckId, err)
+		}
+		if err := encoder.Encode(snapshotDeck{DeckId: deck.DeckId.String(), journalDeck: *encoded}); err != nil {
+			return fmt.Errorf("error exporting deck %s: %w", deck.DeckId, err)
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+	if err := writer.Flush(); err != nil {
+		return exported, fmt.Errorf("error exporting decks: %w", err)
+	}
+	if compressed != nil {
+		if err := compressed.Close(); err != nil {
+			return exported, fmt.Errorf("error exporting decks: %w", err)
+		}
+	}
+	return exported, nil
+}
+
+// Reads a snapshot, in either format, into a repository, and returns the number of decks read. Decks
+// replace the decks with the same ids, and are accessed when they are read. The whole snapshot is read and
+// checked against the deck types of the configuration before any deck is written, so an invalid deck leaves
+// the repository unchanged.
+func ImportSnapshot(store DecksStorer, config configs.DecksConfig, r io.Reader) (int, error) {
+	types, defaultType := deckTypesFromConfig(config)
+	return importSnapshot(store, types, defaultType, r)
+}
+
+func importSnapshot(store DecksStorer, types map[string]*deckType, defaultType string, r io.Reader) (int, error) {
+	reader := bufio.NewReader(r)
+	// Gzip streams start with a magic number, which is never the start of a line of JSON
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		decompressed, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, newError(ErrInvalidParameter, "snapshot", "invalid gzip snapshot: %v", err)
+		}
+		defer decompressed.Close()
+		reader = bufio.NewReader(decompressed)
+	}
+
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	var decks []*models.Deck
+	for {
+		var encoded snapshotDeck
+		err := decoder.Decode(&encoded)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, newError(ErrInvalidParameter, "snapshot", "invalid deck %d of snapshot: %v", len(decks)+1, err)
+		}
+		deck, err := decodeSnapshotDeck(encoded, types, defaultType)
+		if err != nil {
+			return 0, newError(ErrInvalidParameter, "snapshot", "invalid deck %d of snapshot: %v", len(decks)+1, err)
+		}
+		decks = append(decks, deck)
+	}
+
+	// Only a failure of the repository itself can stop writing the decks, keeping those written before
+	for i, deck := range decks {
+		if err := store.Put(deck); err != nil {
+			return i, err
+		}
+	}
+	return len(decks), nil
+}
+
+// Decodes a deck of a snapshot, checking that its remaining cards are among its cards, and that its type and
+// cards are those of a configured deck type
+func decodeSnapshotDeck(encoded snapshotDeck, types map[string]*deckType, defaultType string) (*models.Deck, error) {
+	id, err := uuid.Parse(encoded.DeckId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %s", encoded.DeckId)
+	}
+	deck, err := decodeJournalDeck(id, &encoded.journalDeck)
+	if err != nil {
+		return nil, err
+	}
+	if deck.Remaining < 0 || deck.Remaining > len(deck.Cards) {
+		return nil, fmt.Errorf("%d remaining cards, but deck %s has %d cards", deck.Remaining, id, len(deck.Cards))
+	}
+
+	typeName := deck.Type
+	if typeName == "" {
+		typeName = defaultType
+	}
+	deckType, ok := types[typeName]
+	if !ok {
+		return nil, fmt.Errorf("unknown deck type of deck %s: %s", id, typeName)
+	}
+	cards := deck.Cards
+	for _, pile := range deck.Piles {
+		cards = append(cards[:len(cards):len(cards)], pile...)
+	}
+	if deck.Fairness != nil {
+		cards = append(cards[:len(cards):len(cards)], deck.Fairness.InitialCards...)
+	}
+	for _, card := range cards {
+		if !contains(card.Code, deckType.baseCards) {
+			return nil, fmt.Errorf("card %s of deck %s is not in deck type %s", string(card.Code[:]), id, typeName)
+		}
+	}
+	return deck, nil
+}
+
+// Writes a snapshot of all decks in the given format, and returns the number of decks written
+func (ds *DecksService) ExportDecks(w io.Writer, format string) (int, error) {
+	return ExportSnapshot(ds.decks, w, format)
+}
+
+// Reads a snapshot into the repository of the service, and returns the number of decks read
+func (ds *DecksService) ImportDecks(r io.Reader) (int, error) {
+	return importSnapshot(ds.decks, ds.types, ds.defaultType, r)
+}
@@ -0,0 +1,686 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rnkjnk/decks-api/internal/models"
+	_ "modernc.org/sqlite" // Pure Go SQLite driver, so no cgo is needed
+)
+
+// Schema migrations, applied in order on startup. The index of the last applied migration plus one
+// is kept in the database's user_version, so only new migrations run on an existing database.
+// Never modify a migration that has been released, append a new one instead.
+var sqliteMigrations = []string{
+	`CREATE TABLE decks (
+		id        TEXT PRIMARY KEY,
+		shuffled  INTEGER NOT NULL,
+		remaining INTEGER NOT NULL,
+		cards     TEXT NOT NULL
+	)`,
+	`ALTER TABLE decks ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE decks ADD COLUMN last_accessed INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE decks ADD COLUMN piles TEXT NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE decks ADD COLUMN card_decks TEXT NOT NULL DEFAULT '';
+	ALTER TABLE decks ADD COLUMN pile_decks TEXT NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE decks ADD COLUMN type TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE decks ADD COLUMN seed INTEGER`,
+	`ALTER TABLE decks ADD COLUMN fairness TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE decks ADD COLUMN hidden INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE decks ADD COLUMN history TEXT NOT NULL DEFAULT '[]'`,
+	`ALTER TABLE decks ADD COLUMN labels TEXT NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE decks ADD COLUMN closed INTEGER NOT NULL DEFAULT 0`,
+}
+
+// Common interface of a database and a transaction, so queries can run on both
+type sqlQueryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// SQLite implementation of decks repository
+type DecksSqliteStore struct {
+	db *sql.DB
+	// Source of creation and access timestamps
+	clock Clock
+}
+
+// Creates a new SQLite repository, creating the database file and migrating its schema if needed
+func NewDecksSqliteStore(path string) (*DecksSqliteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database %s: %w", path, err)
+	}
+	// A single connection serializes all access, which makes every transaction atomic
+	// with respect to the others, without having to handle busy errors
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSqlite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &DecksSqliteStore{db: db, clock: SystemClock}, nil
+}
+
+// Closes the underlying database
+func (r *DecksSqliteStore) Close() error {
+	return r.db.Close()
+}
+
+// Gets a deck
+func (r *DecksSqliteStore) Get(id uuid.UUID) (*models.Deck, error) {
+	deck, err := getSqliteDeck(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	// Reading a deck only updates its access time, without writing the rest of the deck again
+	deck.LastAccessed = r.now()
+	if _, err := r.db.Exec("UPDATE decks SET last_accessed = ? WHERE id = ?", encodeTime(deck.LastAccessed), id.String()); err != nil {
+		return nil, fmt.Errorf("error reading deck %s: %w", id, err)
+	}
+	return deck, nil
+}
+
+// Puts a deck
+func (r *DecksSqliteStore) Put(data *models.Deck) error {
+	deck := *data
+	deck.LastAccessed = r.now()
+	return putSqliteDeck(r.db, &deck)
+}
+
+// Creates a deck
+func (r *DecksSqliteStore) Create(data *models.Deck) (uuid.UUID, error) {
+	id := uuid.New()
+	deck := *data
+	deck.DeckId = id
+	deck.CreatedAt = r.now()
+	deck.LastAccessed = deck.CreatedAt
+	// Ids are random, so storing the new deck never overwrites an existing one
+	if err := putSqliteDeck(r.db, &deck); err != nil {
+		return uuid.Nil, err
+	}
+	data.DeckId = id
+	data.CreatedAt = deck.CreatedAt
+	data.LastAccessed = deck.LastAccessed
+	return id, nil
+}
+
+// Deletes a deck
+func (r *DecksSqliteStore) Delete(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM decks WHERE id = ?", id.String())
+	if err != nil {
+		return fmt.Errorf("error deleting deck %s: %w", id, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error deleting deck %s: %w", id, err)
+	}
+	if deleted == 0 {
+		return notFoundError(id)
+	}
+	return nil
+}
+
+// Updates a deck
+func (r *DecksSqliteStore) Update(id uuid.UUID, update func(*models.Deck) error) (*models.Deck, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error updating deck %s: %w", id, err)
+	}
+	// Rolling back a committed transaction is a no-op
+	defer tx.Rollback()
+
+	deck, err := getSqliteDeck(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	deck.LastAccessed = r.now()
+	if err := update(deck); err != nil {
+		return nil, err
+	}
+	if err := putSqliteDeck(tx, deck); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error updating deck %s: %w", id, err)
+	}
+	return deck, nil
+}
+
+// Visits all decks
+func (r *DecksSqliteStore) Each(visit func(*models.Deck) error) error {
+	ids, err := r.queryIds("SELECT id FROM decks ORDER BY id")
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		deck, err := getSqliteDeck(r.db, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := visit(deck); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lists decks
+func (r *DecksSqliteStore) List(filter DecksFilter, after uuid.UUID, limit int) (*DecksPage, error) {
+	// Ids are stored in lowercase, so they sort as strings in the same order as their bytes
+	query := "SELECT id FROM decks WHERE id > ?"
+	args := []any{after.String()}
+	if !filter.CreatedAfter.IsZero() {
+		query += " AND created_at > ?"
+		args = append(args, encodeTime(filter.CreatedAfter))
+	}
+	if filter.Shuffled != nil {
+		query += " AND shuffled = ?"
+		args = append(args, *filter.Shuffled)
+	}
+	if filter.MinRemaining != nil {
+		query += " AND remaining >= ?"
+		args = append(args, *filter.MinRemaining)
+	}
+	if filter.MaxRemaining != nil {
+		query += " AND remaining <= ?"
+		args = append(args, *filter.MaxRemaining)
+	}
+	if filter.Type != "" {
+		query += " AND type = ?"
+		args = append(args, filter.Type)
+	}
+	for key, value := range filter.Labels {
+		query += " AND EXISTS (SELECT 1 FROM json_each(decks.labels) WHERE json_each.key = ? AND json_each.value = ?)"
+		args = append(args, key, value)
+	}
+	// One more deck than the page holds tells if there is a next page
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit+1)
+
+	ids, err := r.queryIds(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	page := DecksPage{Decks: []*models.Deck{}}
+	for _, id := range ids {
+		if len(page.Decks) == limit {
+			page.Next = page.Decks[len(page.Decks)-1].DeckId
+			break
+		}
+		deck, err := getSqliteDeck(r.db, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		page.Decks = append(page.Decks, deck)
+	}
+	return &page, nil
+}
+
+// Returns the ids selected by a query. The ids are read before the decks, since the single connection
+// cannot read decks while a query is open.
+func (r *DecksSqliteStore) queryIds(query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing decks: %w", err)
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error listing decks: %w", err)
+		}
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("error listing decks: invalid id %s", id)
+		}
+		ids = append(ids, parsed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing decks: %w", err)
+	}
+	return ids, nil
+}
+
+// Returns the current time in UTC, the same way timestamps are read back from the database
+func (r *DecksSqliteStore) now() time.Time {
+	return r.clock.Now().UTC()
+}
+
+// Applies all migrations that have not been applied to the database yet
+func migrateSqlite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("error reading schema version: %w", err)
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("error applying migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not support parameters, but the version is always an integer
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("error applying migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func getSqliteDeck(q sqlQueryer, id uuid.UUID) (*models.Deck, error) {
+	var cards, cardDecks, piles, pileDecks, fairness, history, labels string
+	var createdAt, lastAccessed int64
+	var seed sql.NullInt64
+	deck := models.Deck{DeckId: id}
+	err := q.QueryRow(
+		"SELECT shuffled, remaining, type, seed, fairness, hidden, labels, closed, cards, card_decks, piles, pile_decks, history, created_at, last_accessed FROM decks WHERE id = ?",
+		id.String(),
+	).Scan(&deck.Shuffled, &deck.Remaining, &deck.Type, &seed, &fairness, &deck.Hidden, &labels, &deck.Closed, &cards, &cardDecks, &piles, &pileDecks, &history, &createdAt, &lastAccessed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError(id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading deck %s: %w", id, err)
+	}
+	deck.Cards, err = decodeCards(cards, cardDecks)
+	if err != nil {
+		return nil, fmt.Errorf("error reading deck %s: %w", id, err)
+	}
+	deck.Piles, err = decodePiles(piles, pileDecks)
+	if err != nil {
+		return nil, fmt.Errorf("error reading deck %s: %w", id, err)
+	}
+	if seed.Valid {
+		deck.Seed = &seed.Int64
+	}
+	deck.Fairness, err = decodeFairness(fairness)
+	if err != nil {
+		return nil, fmt.Errorf("error reading deck %s: %w", id, err)
+	}
+	deck.History, err = decodeHistory(history)
+	if err != nil {
+		return nil, fmt.Errorf("error reading deck %s: %w", id, err)
+	}
+	deck.Labels, err = decodeLabels(labels)
+	if err != nil {
+		return nil, fmt.Errorf("error reading deck %s: %w", id, err)
+	}
+	deck.CreatedAt = decodeTime(createdAt)
+	deck.LastAccessed = decodeTime(lastAccessed)
+	return &deck, nil
+}
+
+func putSqliteDeck(q sqlQueryer, deck *models.Deck) error {
+	cards, cardDecks := encodeCards(deck.Cards)
+	piles, pileDecks, err := encodePiles(deck.Piles)
+	if err != nil {
+		return fmt.Errorf("error storing deck %s: %w", deck.DeckId, err)
+	}
+	fairness, err := encodeFairness(deck.Fairness)
+	if err != nil {
+		return fmt.Errorf("error storing deck %s: %w", deck.DeckId, err)
+	}
+	history, err := encodeHistory(deck.History)
+	if err != nil {
+		return fmt.Errorf("error storing deck %s: %w", deck.DeckId, err)
+	}
+	labels, err := encodeLabels(deck.Labels)
+	if err != nil {
+		return fmt.Errorf("error storing deck %s: %w", deck.DeckId, err)
+	}
+	_, err = q.Exec(
+		`INSERT INTO decks (id, shuffled, remaining, type, seed, fairness, hidden, labels, closed, cards, card_decks, piles, pile_decks, history, created_at, last_accessed)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET shuffled = excluded.shuffled, remaining = excluded.remaining, type = excluded.type,
+			seed = excluded.seed, fairness = excluded.fairness, hidden = excluded.hidden, labels = excluded.labels, closed = excluded.closed,
+			cards = excluded.cards, card_decks = excluded.card_decks, piles = excluded.piles, pile_decks = excluded.pile_decks,
+			history = excluded.history, created_at = excluded.created_at, last_accessed = excluded.last_accessed`,
+		deck.DeckId.String(), deck.Shuffled, deck.Remaining, deck.Type, deck.Seed, fairness, deck.Hidden, labels, deck.Closed, cards, cardDecks, piles, pileDecks,
+		history, encodeTime(deck.CreatedAt), encodeTime(deck.LastAccessed),
+	)
+	if err != nil {
+		return fmt.Errorf("error storing deck %s: %w", deck.DeckId, err)
+	}
+	return nil
+}
+
+// Encodes cards in their exact order. Codes are encoded as a string of two-character codes, without separators,
+// so that codes made of any characters are stored unambiguously. The numbers of the decks of a shoe the cards
+// come from are encoded separately as a comma separated list, which is empty if the cards are not from a shoe.
+func encodeCards(cards []models.Card) (string, string) {
+	runes := make([]rune, 0, 2*len(cards))
+	shoe := false
+	for _, card := range cards {
+		runes = append(runes, card.Code[0], card.Code[1])
+		shoe = shoe || card.Deck != 0
+	}
+	if !shoe {
+		return string(runes), ""
+	}
+	decks := make([]string, len(cards))
+	for i, card := range cards {
+		decks[i] = strconv.Itoa(card.Deck)
+	}
+	return string(runes), strings.Join(decks, ",")
+}
+
+// Decodes cards encoded by encodeCards
+func decodeCards(codes string, decks string) ([]models.Card, error) {
+	runes := []rune(codes)
+	if len(runes)%2 != 0 {
+		return nil, fmt.Errorf("invalid card encoding: %s", codes)
+	}
+	cards := make([]models.Card, len(runes)/2)
+	for i := range cards {
+		cards[i].Code[0] = runes[2*i]
+		cards[i].Code[1] = runes[2*i+1]
+	}
+	if decks == "" {
+		return cards, nil
+	}
+	numbers := strings.Split(decks, ",")
+	if len(numbers) != len(cards) {
+		return nil, fmt.Errorf("invalid deck numbers encoding: %s", decks)
+	}
+	for i, number := range numbers {
+		deck, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deck numbers encoding: %s", decks)
+		}
+		cards[i].Deck = deck
+	}
+	return cards, nil
+}
+
+// Encodes piles as two JSON objects, of pile names and their cards' codes and deck numbers encoded by encodeCards
+func encodePiles(piles map[string][]models.Card) (string, string, error) {
+	codes := make(map[string]string, len(piles))
+	decks := make(map[string]string)
+	for name, cards := range piles {
+		codes[name], decks[name] = encodeCards(cards)
+		if decks[name] == "" {
+			delete(decks, name)
+		}
+	}
+	encodedCodes, err := json.Marshal(codes)
+	if err != nil {
+		return "", "", err
+	}
+	encodedDecks, err := json.Marshal(decks)
+	if err != nil {
+		return "", "", err
+	}
+	return string(encodedCodes), string(encodedDecks), nil
+}
+
+// Decodes piles encoded by encodePiles, a deck without piles has none
+func decodePiles(encodedCodes string, encodedDecks string) (map[string][]models.Card, error) {
+	var codes, decks map[string]string
+	if err := json.Unmarshal([]byte(encodedCodes), &codes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(encodedDecks), &decks); err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	piles := make(map[string][]models.Card, len(codes))
+	for name, cards := range codes {
+		decoded, err := decodeCards(cards, decks[name])
+		if err != nil {
+			return nil, err
+		}
+		piles[name] = decoded
+	}
+	return piles, nil
+}
+
+// Provably fair shuffle as stored in the database
+type sqliteFairness struct {
+	ServerSeed string `json:"server_seed"`
+	Commitment string `json:"commitment"`
+	ClientSeed string `json:"client_seed"`
+	Revealed   bool   `json:"revealed"`
+	Cards      string `json:"cards"`
+	CardDecks  string `json:"card_decks,omitempty"`
+}
+
+// Encodes the provably fair shuffle of a deck as a JSON object, with its initial cards encoded by encodeCards,
+// and a deck that is not provably fair as an empty string
+func encodeFairness(fairness *models.Fairness) (string, error) {
+	if fairness == nil {
+		return "", nil
+	}
+	encoded := sqliteFairness{
+		ServerSeed: fairness.ServerSeed,
+		Commitment: fairness.Commitment,
+		ClientSeed: fairness.ClientSeed,
+		Revealed:   fairness.Revealed,
+	}
+	encoded.Cards, encoded.CardDecks = encodeCards(fairness.InitialCards)
+	result, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// Decodes a provably fair shuffle encoded by encodeFairness
+func decodeFairness(encoded string) (*models.Fairness, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	var decoded sqliteFairness
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		return nil, err
+	}
+	cards, err := decodeCards(decoded.Cards, decoded.CardDecks)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Fairness{
+		ServerSeed:   decoded.ServerSeed,
+		Commitment:   decoded.Commitment,
+		ClientSeed:   decoded.ClientSeed,
+		Revealed:     decoded.Revealed,
+		InitialCards: cards,
+	}, nil
+}
+
+// Change of an event of the history of a deck as stored in the database
+type sqliteChange struct {
+	Cards        *string  `json:"cards,omitempty"`
+	CardDecks    string   `json:"card_decks,omitempty"`
+	Remaining    int      `json:"remaining"`
+	Shuffled     bool     `json:"shuffled"`
+	Seed         *int64   `json:"seed,omitempty"`
+	Piles        string   `json:"piles,omitempty"`
+	PileDecks    string   `json:"pile_decks,omitempty"`
+	RemovedPiles []string `json:"removed_piles,omitempty"`
+}
+
+// Event of the history of a deck as stored in the database
+type sqliteEvent struct {
+	Type    string            `json:"type"`
+	Time    int64             `json:"time"`
+	Actor   string            `json:"actor,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+	sqliteChange
+}
+
+// Encodes the history of a deck as a JSON array of events encoded by encodeEvents
+func encodeHistory(history []models.DeckEvent) (string, error) {
+	encoded, err := encodeEvents(history)
+	if err != nil {
+		return "", err
+	}
+	result, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// Decodes a history encoded by encodeHistory, a deck without events has no history
+func decodeHistory(encoded string) ([]models.DeckEvent, error) {
+	var decoded []sqliteEvent
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		return nil, err
+	}
+	if len(decoded) == 0 {
+		return nil, nil
+	}
+	return decodeEvents(decoded)
+}
+
+// Encodes events with their timestamps encoded by encodeTime, and their changes encoded by encodeChange
+func encodeEvents(events []models.DeckEvent) ([]sqliteEvent, error) {
+	encoded := make([]sqliteEvent, len(events))
+	for i, event := range events {
+		change, err := encodeChange(event.Change)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = sqliteEvent{
+			Type:         event.Type,
+			Time:         encodeTime(event.Time),
+			Actor:        event.Actor,
+			Details:      event.Details,
+			sqliteChange: change,
+		}
+	}
+	return encoded, nil
+}
+
+// Decodes events encoded by encodeEvents
+func decodeEvents(encoded []sqliteEvent) ([]models.DeckEvent, error) {
+	events := make([]models.DeckEvent, len(encoded))
+	for i, event := range encoded {
+		change, err := decodeChange(event.sqliteChange)
+		if err != nil {
+			return nil, err
+		}
+		events[i] = models.DeckEvent{
+			Type:    event.Type,
+			Time:    decodeTime(event.Time),
+			Actor:   event.Actor,
+			Details: event.Details,
+			Change:  change,
+		}
+	}
+	return events, nil
+}
+
+// Encodes the change of an event, with its cards and piles encoded by encodeCards and encodePiles.
+// Cards and piles that did not change are left out.
+func encodeChange(change models.DeckChange) (sqliteChange, error) {
+	encoded := sqliteChange{
+		Remaining:    change.Remaining,
+		Shuffled:     change.Shuffled,
+		Seed:         change.Seed,
+		RemovedPiles: change.RemovedPiles,
+	}
+	if change.Cards != nil {
+		cards, cardDecks := encodeCards(change.Cards)
+		encoded.Cards = &cards
+		encoded.CardDecks = cardDecks
+	}
+	if change.Piles != nil {
+		piles, pileDecks, err := encodePiles(change.Piles)
+		if err != nil {
+			return sqliteChange{}, err
+		}
+		encoded.Piles = piles
+		encoded.PileDecks = pileDecks
+	}
+	return encoded, nil
+}
+
+// Decodes a change encoded by encodeChange
+func decodeChange(encoded sqliteChange) (models.DeckChange, error) {
+	change := models.DeckChange{
+		Remaining:    encoded.Remaining,
+		Shuffled:     encoded.Shuffled,
+		Seed:         encoded.Seed,
+		RemovedPiles: encoded.RemovedPiles,
+	}
+	if encoded.Cards != nil {
+		cards, err := decodeCards(*encoded.Cards, encoded.CardDecks)
+		if err != nil {
+			return models.DeckChange{}, err
+		}
+		change.Cards = cards
+	}
+	if encoded.Piles != "" {
+		piles, err := decodePiles(encoded.Piles, encoded.PileDecks)
+		if err != nil {
+			return models.DeckChange{}, err
+		}
+		change.Piles = piles
+	}
+	return change, nil
+}
+
+// Encodes the labels of a deck as a JSON object
+func encodeLabels(labels map[string]string) (string, error) {
+	if labels == nil {
+		return "{}", nil
+	}
+	result, err := json.Marshal(labels)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// Decodes labels encoded by encodeLabels, a deck without labels has no labels map
+func decodeLabels(encoded string) (map[string]string, error) {
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		return nil, err
+	}
+	if len(decoded) == 0 {
+		return nil, nil
+	}
+	return decoded, nil
+}
+
+// Encodes a timestamp as nanoseconds since the Unix epoch, and the zero time as 0
+func encodeTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// Decodes a timestamp encoded by encodeTime
+func decodeTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos).UTC()
+}
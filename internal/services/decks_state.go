@@ -0,0 +1,50 @@
+package services
+
+import (
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// The methods changing a deck return, if requested by their options, the summary of the deck as it was right
+// after the request, read in the same update of the repository as the change.
+
+// Returns the state of a deck, with its remaining cards unless they are hidden
+func (ds *DecksService) DescribeDeck(deckId string) (*dto.DeckStateResponse, error) {
+
+	id, err := parseDeckId(deckId)
+	if err != nil {
+		return nil, err
+	}
+
+	deck, err := ds.decks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []models.Card
+	if !deck.Hidden {
+		cards = deck.Cards[len(deck.Cards)-deck.Remaining:]
+	}
+
+	return ds.deckStateOf(deck, cards), nil
+}
+
+// Returns the summary of a deck if the state of the deck is requested, otherwise nil
+func (ds *DecksService) stateOf(state bool, deck *models.Deck) *dto.DeckSummaryDto {
+	if !state {
+		return nil
+	}
+	summary := ds.deckSummaryOf(deck)
+	return &summary
+}
+
+// Returns the state of a deck with the given cards
+func (ds *DecksService) deckStateOf(deck *models.Deck, cards []models.Card) *dto.DeckStateResponse {
+	result := dto.DeckStateResponse{
+		Deck: ds.deckSummaryOf(deck),
+	}
+	if len(cards) > 0 {
+		result.Cards = ds.cardDtosFromIds(deck, cards)
+	}
+	return &result
+}
@@ -1,11 +1,15 @@
 package services
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/configs"
 )
 
 // Repository interface
@@ -14,6 +18,54 @@ type DecksStorer interface {
 	Put(*models.Deck) error
 	Create(*models.Deck) (uuid.UUID, error)
 	Delete(id uuid.UUID) error
+	// Atomically applies the update function to a deck. The function receives a copy of the stored deck,
+	// and the modified copy is stored only if the function returns no error.
+	Update(id uuid.UUID, update func(*models.Deck) error) (*models.Deck, error)
+	// Calls the visit function with a copy of every deck, in the order of their ids, until it returns an error.
+	// Visiting a deck does not update its access time. Decks created or deleted while visiting may be missed.
+	Each(visit func(*models.Deck) error) error
+	// Returns a page of copies of the decks matching the filter, in the order of their ids, starting after the
+	// given id, or with the first deck if it is uuid.Nil. Listing decks does not update their access time.
+	List(filter DecksFilter, after uuid.UUID, limit int) (*DecksPage, error)
+}
+
+// Criteria decks must all match to be listed, zero values match all decks
+type DecksFilter struct {
+	CreatedAfter time.Time         // Only decks created after this time
+	Shuffled     *bool             // Only shuffled or unshuffled decks
+	MinRemaining *int              // Only decks with at least this number of remaining cards
+	MaxRemaining *int              // Only decks with at most this number of remaining cards
+	Type         string            // Only decks of this type
+	Labels       map[string]string // Only decks with all these labels
+}
+
+// A page of listed decks
+type DecksPage struct {
+	Decks []*models.Deck // The decks, in the order of their ids
+	Next  uuid.UUID      // Id of the last deck of the page if more decks match, uuid.Nil otherwise
+}
+
+// Creates the repository selected in the configuration
+func NewDecksStore(config configs.StoreConfig) (DecksStorer, error) {
+	switch config.Type {
+	case "", "memory":
+		store := NewExpiringDecksInMemoryStore(config.TTL, SystemClock)
+		if config.TTL > 0 {
+			store.StartJanitor(config.EvictionInterval)
+		}
+		return store, nil
+	case "sqlite":
+		return NewDecksSqliteStore(config.Path)
+	case "journal":
+		store, err := NewDecksJournalStore(config.Path)
+		if err != nil {
+			return nil, err
+		}
+		store.StartCompaction(config.CompactionInterval)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown store type: %s", config.Type)
+	}
 }
 
 // Thread safe in-memory map implementation of decks repository
@@ -22,31 +74,49 @@ type DecksInMemoryStore struct {
 	mu sync.RWMutex
 	// Internal map to store data
 	decks map[uuid.UUID]models.Deck
+	// Source of creation and access timestamps
+	clock Clock
+	// Decks not accessed for this long are expired, zero means decks never expire
+	ttl time.Duration
+	// Background eviction of expired decks, see StartJanitor
+	janitor *janitor
 }
 
 // Creates a new in-memory repository
 func NewDecksInMemoryStore() DecksStorer {
+	return NewExpiringDecksInMemoryStore(0, SystemClock)
+}
+
+// Creates a new in-memory repository in which decks expire when they are not accessed for the given time
+func NewExpiringDecksInMemoryStore(ttl time.Duration, clock Clock) *DecksInMemoryStore {
 	repository := DecksInMemoryStore{
 		decks: make(map[uuid.UUID]models.Deck),
+		clock: clock,
+		ttl:   ttl,
 	}
 	return &repository
 }
 
 // Gets a deck
 func (r *DecksInMemoryStore) Get(id uuid.UUID) (*models.Deck, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	if data, ok := r.decks[id]; ok {
-		return &data, nil
+	// Reading a deck updates its access time, so a write lock is needed
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := r.get(id)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("data not found for id: %s", id)
+	r.decks[id] = *data
+	return copyDeck(data), nil
 }
 
 // Puts a deck
 func (r *DecksInMemoryStore) Put(data *models.Deck) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.decks[data.DeckId] = *data
+	deck := copyDeck(data)
+	deck.LastAccessed = r.now()
+	r.decks[data.DeckId] = *deck
 	return nil
 }
 
@@ -56,7 +126,9 @@ func (r *DecksInMemoryStore) Create(data *models.Deck) (uuid.UUID, error) {
 	defer r.mu.Unlock()
 	id := uuid.New()
 	data.DeckId = id
-	r.decks[id] = *data
+	data.CreatedAt = r.now()
+	data.LastAccessed = data.CreatedAt
+	r.decks[id] = *copyDeck(data)
 	return id, nil
 }
 
@@ -64,9 +136,202 @@ func (r *DecksInMemoryStore) Create(data *models.Deck) (uuid.UUID, error) {
 func (r *DecksInMemoryStore) Delete(id uuid.UUID) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, ok := r.decks[id]; !ok {
-		return fmt.Errorf("data not found for id: %s", id)
+	if _, err := r.get(id); err != nil {
+		return err
 	}
 	delete(r.decks, id)
 	return nil
 }
+
+// Updates a deck
+func (r *DecksInMemoryStore) Update(id uuid.UUID, update func(*models.Deck) error) (*models.Deck, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// The update function works on a copy, so a failed update leaves the stored deck untouched
+	deck, err := r.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(deck); err != nil {
+		return nil, err
+	}
+	r.decks[id] = *deck
+	return copyDeck(deck), nil
+}
+
+// Visits all decks
+func (r *DecksInMemoryStore) Each(visit func(*models.Deck) error) error {
+	r.mu.RLock()
+	ids := sortedIds(r.decks)
+	r.mu.RUnlock()
+	// The lock is not held while visiting, so the visit function can use the repository
+	for _, id := range ids {
+		r.mu.RLock()
+		data, ok := r.decks[id]
+		expired := ok && r.expired(&data, r.now())
+		r.mu.RUnlock()
+		if !ok || expired {
+			continue
+		}
+		if err := visit(copyDeck(&data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lists decks
+func (r *DecksInMemoryStore) List(filter DecksFilter, after uuid.UUID, limit int) (*DecksPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := r.now()
+	return listDecks(sortedIds(r.decks), after, limit, func(id uuid.UUID) *models.Deck {
+		deck := r.decks[id]
+		if r.expired(&deck, now) || !filter.matches(&deck) {
+			return nil
+		}
+		return copyDeck(&deck)
+	}), nil
+}
+
+// Removes all expired decks and returns how many were removed
+func (r *DecksInMemoryStore) EvictExpired() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	evicted := 0
+	for id, deck := range r.decks {
+		if r.expired(&deck, now) {
+			delete(r.decks, id)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Returns the number of stored decks, including expired decks that have not been evicted yet
+func (r *DecksInMemoryStore) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.decks)
+}
+
+// Returns a copy of a deck with its access time updated, the caller must hold the write lock.
+// Expired decks that have not been evicted yet are removed and reported as not found.
+func (r *DecksInMemoryStore) get(id uuid.UUID) (*models.Deck, error) {
+	data, ok := r.decks[id]
+	if !ok {
+		return nil, notFoundError(id)
+	}
+	now := r.now()
+	if r.expired(&data, now) {
+		delete(r.decks, id)
+		return nil, notFoundError(id)
+	}
+	deck := copyDeck(&data)
+	deck.LastAccessed = now
+	return deck, nil
+}
+
+// Returns true if a deck has not been accessed within the time to live
+func (r *DecksInMemoryStore) expired(deck *models.Deck, now time.Time) bool {
+	return r.ttl > 0 && now.Sub(deck.LastAccessed) > r.ttl
+}
+
+// Returns the current time, without a monotonic clock reading so that timestamps compare equal
+// to the same timestamps read from other repositories
+func (r *DecksInMemoryStore) now() time.Time {
+	return r.clock.Now().UTC()
+}
+
+// Returns the ids of a map of decks in ascending order
+func sortedIds[T any](decks map[uuid.UUID]T) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(decks))
+	for id := range decks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	return ids
+}
+
+// Returns true if a deck matches all criteria of the filter
+func (f DecksFilter) matches(deck *models.Deck) bool {
+	if !f.CreatedAfter.IsZero() && !deck.CreatedAt.After(f.CreatedAfter) {
+		return false
+	}
+	if f.Shuffled != nil && deck.Shuffled != *f.Shuffled {
+		return false
+	}
+	if f.MinRemaining != nil && deck.Remaining < *f.MinRemaining {
+		return false
+	}
+	if f.MaxRemaining != nil && deck.Remaining > *f.MaxRemaining {
+		return false
+	}
+	if f.Type != "" && deck.Type != f.Type {
+		return false
+	}
+	for key, value := range f.Labels {
+		if label, ok := deck.Labels[key]; !ok || label != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Builds a page of the decks returned by the match function, which returns nil for decks that do not match,
+// from the ids following the given id, in ascending order
+func listDecks(ids []uuid.UUID, after uuid.UUID, limit int, match func(uuid.UUID) *models.Deck) *DecksPage {
+	// The ids are sorted, so the page starts at the first id greater than the cursor
+	start := sort.Search(len(ids), func(i int) bool {
+		return bytes.Compare(ids[i][:], after[:]) > 0
+	})
+	page := DecksPage{Decks: []*models.Deck{}}
+	for _, id := range ids[start:] {
+		deck := match(id)
+		if deck == nil {
+			continue
+		}
+		if len(page.Decks) == limit {
+			page.Next = page.Decks[len(page.Decks)-1].DeckId
+			break
+		}
+		page.Decks = append(page.Decks, deck)
+	}
+	return &page
+}
+
+// Returns the error for a deck missing from a repository
+func notFoundError(id uuid.UUID) error {
+	return newError(ErrNotFound, "id", "data not found for id: %s", id)
+}
+
+// Copies a deck, so that callers never share the card slices with the stored deck, only its immutable events
+func copyDeck(deck *models.Deck) *models.Deck {
+	result := *deck
+	result.Cards = copyCards(deck.Cards)
+	if deck.Piles != nil {
+		result.Piles = make(map[string][]models.Card, len(deck.Piles))
+		for name, cards := range deck.Piles {
+			result.Piles[name] = copyCards(cards)
+		}
+	}
+	if deck.Labels != nil {
+		result.Labels = make(map[string]string, len(deck.Labels))
+		for key, value := range deck.Labels {
+			result.Labels[key] = value
+		}
+	}
+	// Events are never modified once recorded, so their cards and details are shared with the stored deck
+	if deck.History != nil {
+		result.History = append([]models.DeckEvent{}, deck.History...)
+	}
+	if deck.Fairness != nil {
+		fairness := *deck.Fairness
+		fairness.InitialCards = copyCards(deck.Fairness.InitialCards)
+		result.Fairness = &fairness
+	}
+	return &result
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Name of the deck type defined by the suits, values and extras at the top of the decks configuration
+const StandardDeckType = "standard"
+
+// A type of deck, with the names and codes of all its possible cards
+type deckType struct {
+	name      string                              // Name of the deck type
+	suits     map[rune]string                     // Names of all possible suits
+	values    map[rune]string                     // Names of all possible card values
+	extras    map[[2]rune]configs.ExtraCardConfig // Cards outside the grid of suits and values, by code
+	baseCards [][2]rune                           // Codes of all possible cards
+}
+
+func newDeckType(name string, config configs.DeckTypeConfig) *deckType {
+	// We initialize the maps of all suits and values with first character (code) as key,
+	// as well as all suit and value cudes
+	suits, suitCodes := dictionarize(config.Suits)
+	values, valueCodes := dictionarize(config.Values)
+	// now the array of all possible cards, followed by the extra cards
+	cards := allCards(suitCodes, valueCodes)
+	extras, extraCodes := extraCards(config.Extras, cards)
+	cards = append(cards, extraCodes...)
+
+	return &deckType{
+		name:      name,
+		suits:     suits,
+		values:    values,
+		extras:    extras,
+		baseCards: cards,
+	}
+}
+
+// Returns all deck types of a configuration by name, and the name of the default type
+func deckTypesFromConfig(config configs.DecksConfig) (map[string]*deckType, string) {
+	types := make(map[string]*deckType, len(config.Types)+1)
+	if len(config.Suits) > 0 || len(config.Values) > 0 || len(config.Extras) > 0 {
+		types[StandardDeckType] = newDeckType(StandardDeckType, configs.DeckTypeConfig{
+			Suits:  config.Suits,
+			Values: config.Values,
+			Extras: config.Extras,
+		})
+	}
+	for name, typeConfig := range config.Types {
+		if _, exists := types[name]; exists {
+			panic("Bad configuration, multiple deck types named " + name)
+		}
+		types[name] = newDeckType(name, typeConfig)
+	}
+
+	defaultType := config.DefaultType
+	if defaultType == "" {
+		defaultType = StandardDeckType
+	}
+	if _, exists := types[defaultType]; !exists {
+		panic("Bad configuration, the default deck type is not defined: " + defaultType)
+	}
+
+	return types, defaultType
+}
+
+// Returns the type of a deck. Decks created before deck types existed, or whose type has been removed
+// from the configuration since, are of the default type.
+func (ds *DecksService) deckTypeOf(deck *models.Deck) *deckType {
+	if t, ok := ds.types[deck.Type]; ok {
+		return t
+	}
+	return ds.types[ds.defaultType]
+}
+
+// Lists all deck types
+func (ds *DecksService) ListDeckTypes() (*dto.DeckTypesResponse, error) {
+
+	names := make([]string, 0, len(ds.types))
+	for name := range ds.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := dto.DeckTypesResponse{
+		Types: make([]dto.DeckTypeDto, len(names)),
+	}
+	for i, name := range names {
+		t := ds.types[name]
+		result.Types[i] = dto.DeckTypeDto{
+			Name:    name,
+			Default: name == ds.defaultType,
+			Size:    len(t.baseCards),
+			Cards:   t.cardDtos(buildShoe(t.baseCards, 1)),
+		}
+	}
+
+	return &result, nil
+}
+
+// Returns a slice of card DTOs from a slice of cards
+func (t *deckType) cardDtos(cards []models.Card) []dto.CardDto {
+	result := make([]dto.CardDto, len(cards))
+	for i, card := range cards {
+		result[i] = dto.CardDto{
+			Value: t.values[card.Code[0]],
+			Suit:  t.suits[card.Code[1]],
+			Code:  string(card.Code[:]),
+			Deck:  card.Deck,
+		}
+		if extra, ok := t.extras[card.Code]; ok {
+			result[i].Value = extra.Value
+			result[i].Suit = extra.Suit
+		}
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors returned by the decks service and repositories, to be checked with errors.Is
+var (
+	ErrNotFound          = errors.New("not found")          // The deck does not exist
+	ErrInvalidId         = errors.New("invalid id")         // The deck id is not a valid uuid
+	ErrInvalidCardCode   = errors.New("invalid card code")  // A card code is badly formatted
+	ErrInvalidParameter  = errors.New("invalid parameter")  // Any other parameter is badly formatted or out of range
+	ErrInsufficientCards = errors.New("insufficient cards") // More cards were requested than remain in the deck
+	ErrEmptyDeck         = errors.New("empty deck")         // No cards remain in the deck
+	ErrCardNotDrawn      = errors.New("card not drawn")     // A card does not belong to the deck, or has not been drawn from it
+	ErrPileNotFound      = errors.New("pile not found")     // The deck has no pile with the given name
+	ErrNotProvablyFair   = errors.New("not provably fair")  // The deck was not shuffled provably fair
+	ErrCardNotInDeck     = errors.New("card not in deck")   // A card to draw is not among the remaining cards of the deck
+	ErrDeckHidden        = errors.New("deck hidden")        // The remaining cards of the deck can only be peeked at
+	ErrDeckClosed        = errors.New("deck closed")        // The deck is closed, or no longer open to the requested change
+)
+
+// An error of a known kind, caused by a specific field of a request
+type Error struct {
+	Kind    error  // One of the error kinds above
+	Field   string // The name of the offending field, empty if no single field caused the error
+	Message string // Human readable description of the error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Makes errors.Is match the kind of the error
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Creates an error of a given kind, with a formatted message
+func newError(kind error, field string, format string, args ...any) *Error {
+	return &Error{
+		Kind:    kind,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
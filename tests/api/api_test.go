@@ -0,0 +1,466 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnkjnk/decks-api/internal/api"
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// We will test with a hard-coded standard deck, so config file modifications don't spoil tests
+func createMockDecksConfiguration() configs.DecksConfig {
+	decksConfig := configs.DecksConfig{
+		Suits:  []string{"CLUBS", "DIAMONDS", "HEARTS", "SPADES"},
+		Values: []string{"ACE", "2", "3", "4", "5", "6", "7", "8", "9", "TEN", "JACK", "QUEEN", "KING"},
+	}
+	return decksConfig
+}
+
+// Creates a router with all routes, backed by an in-memory repository
+func createRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	service := services.NewDecksService(createMockDecksConfiguration(), services.NewDecksInMemoryStore())
+	api.NewHandlers(service).SetupRoutes(router)
+	return router
+}
+
+// Serves a request and decodes the JSON response body into result
+func serve(t *testing.T, router *gin.Engine, method string, url string, result any) int {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, url, nil))
+	if err := json.Unmarshal(recorder.Body.Bytes(), result); err != nil {
+		t.Errorf("Unexpected error decoding %s: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code
+}
+
+// Creates a deck through the API and returns its id
+func createDeck(t *testing.T, router *gin.Engine, query string) string {
+	var created dto.CreateDeckResponse
+	status := serve(t, router, http.MethodPost, "/deck"+query, &created)
+	if status != http.StatusOK {
+		t.Fatalf("Unexpected status creating deck. Expected: %+v, Got: %+v", http.StatusOK, status)
+	}
+	return created.DeckId
+}
+
+func TestErrors_MappedToStatusCodes(t *testing.T) {
+
+	router := createRouter()
+
+	id := createDeck(t, router, "?shuffle=false&cards=AC,2C")
+
+	serve(t, router, http.MethodPost, "/deck/"+id+"/draw-cards?draw=2", &dto.DrawCardsResponse{})
+
+	hiddenId := createDeck(t, router, "?hidden=true")
+
+	closedId := createDeck(t, router, "")
+	serve(t, router, http.MethodPost, "/deck/"+closedId+"/close", &dto.CloseDeckResponse{})
+
+	tests := []struct {
+		name             string
+		method           string
+		url              string
+		expectedStatus   int
+		expectedResponse dto.ErrorResponse
+	}{
+		{
+			name:           "unknown deck",
+			method:         http.MethodGet,
+			url:            "/deck/5b25d675-b285-4713-b976-9571a404f88a/open",
+			expectedStatus: http.StatusNotFound,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "not_found",
+				Message: "data not found for id: 5b25d675-b285-4713-b976-9571a404f88a",
+				Field:   "id",
+			},
+		},
+		{
+			name:           "malformed id",
+			method:         http.MethodGet,
+			url:            "/deck/not-a-uuid/open",
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_id",
+				Message: "error parsing id: not-a-uuid",
+				Field:   "id",
+			},
+		},
+		{
+			name:           "bad card code",
+			method:         http.MethodPost,
+			url:            "/deck?cards=AC,10C",
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_card_code",
+				Message: "invalid card code: 10C",
+				Field:   "cards",
+			},
+		},
+		{
+			name:           "unknown deck type",
+			method:         http.MethodPost,
+			url:            "/deck?type=tarot",
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "unknown deck type: tarot",
+				Field:   "type",
+			},
+		},
+		{
+			name:           "zero deck count",
+			method:         http.MethodPost,
+			url:            "/deck?deck_count=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "deck count must be between 1 and 100, got: 0",
+				Field:   "deck_count",
+			},
+		},
+		{
+			name:           "bad draw count",
+			method:         http.MethodPost,
+			url:            "/deck/" + id + "/draw-cards?draw=many",
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "invalid value for draw: many",
+				Field:   "draw",
+			},
+		},
+		{
+			name:           "drawing more than remaining",
+			method:         http.MethodPost,
+			url:            "/deck/" + id + "/draw-cards?draw=1",
+			expectedStatus: http.StatusConflict,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "insufficient_cards",
+				Message: "1 card(s) requested, but deck id " + id + " has only 0 card(s) left",
+				Field:   "draw",
+			},
+		},
+		{
+			name:           "verifying deck that is not provably fair",
+			method:         http.MethodGet,
+			url:            "/deck/" + id + "/verify",
+			expectedStatus: http.StatusConflict,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "not_provably_fair",
+				Message: "deck id " + id + " was not shuffled provably fair",
+				Field:   "id",
+			},
+		},
+		{
+			name:           "unknown operation",
+			method:         http.MethodPost,
+			url:            "/deck/" + hiddenId + "/operations?operation=juggle",
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "invalid operation: juggle",
+				Field:   "operation",
+			},
+		},
+		{
+			name:           "deleting unknown deck",
+			method:         http.MethodDelete,
+			url:            "/deck/5b25d675-b285-4713-b976-9571a404f88a",
+			expectedStatus: http.StatusNotFound,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "not_found",
+				Message: "data not found for id: 5b25d675-b285-4713-b976-9571a404f88a",
+				Field:   "id",
+			},
+		},
+		{
+			name:           "drawing from closed deck",
+			method:         http.MethodPost,
+			url:            "/deck/" + closedId + "/draw-cards?draw=1",
+			expectedStatus: http.StatusConflict,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "deck_closed",
+				Message: "deck id " + closedId + " is closed",
+				Field:   "id",
+			},
+		},
+		{
+			name:           "bad listing filter",
+			method:         http.MethodGet,
+			url:            "/decks?shuffled=maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "invalid value for shuffled: maybe",
+				Field:   "shuffled",
+			},
+		},
+		{
+			name:           "cloning hidden deck without reshuffle",
+			method:         http.MethodPost,
+			url:            "/deck/" + hiddenId + "/clone",
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "the cards of deck id " + hiddenId + " are hidden, it can only be cloned with a reshuffle",
+				Field:   "reshuffle",
+			},
+		},
+		{
+			name:           "opening hidden deck",
+			method:         http.MethodGet,
+			url:            "/deck/" + hiddenId + "/open",
+			expectedStatus: http.StatusForbidden,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "deck_hidden",
+				Message: "the cards of deck id " + hiddenId + " are hidden, they can only be peeked at",
+				Field:   "id",
+			},
+		},
+		{
+			name:           "opening empty deck",
+			method:         http.MethodGet,
+			url:            "/deck/" + id + "/open",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "empty_deck",
+				Message: "no cards remaining in deck id: " + id,
+				Field:   "id",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var response dto.ErrorResponse
+		status := serve(t, router, test.method, test.url, &response)
+
+		if status != test.expectedStatus {
+			t.Errorf("Unexpected status for %s. Expected: %+v, Got: %+v", test.name, test.expectedStatus, status)
+		}
+
+		if !reflect.DeepEqual(response, test.expectedResponse) {
+			t.Errorf("Unexpected response for %s. Expected: %+v, Got: %+v", test.name, test.expectedResponse, response)
+		}
+	}
+}
+
+func TestListDeckTypes_ListsDefaultType(t *testing.T) {
+
+	router := createRouter()
+
+	var response dto.DeckTypesResponse
+	status := serve(t, router, http.MethodGet, "/deck-types", &response)
+
+	if status != http.StatusOK {
+		t.Errorf("Unexpected status. Expected: %+v, Got: %+v", http.StatusOK, status)
+	}
+
+	if len(response.Types) != 1 || response.Types[0].Name != "standard" || !response.Types[0].Default || response.Types[0].Size != 52 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
+func TestHistory_RecordsActorAndUndo(t *testing.T) {
+
+	router := createRouter()
+
+	id := createDeck(t, router, "?shuffle=false&cards=AC,2C")
+
+	request := httptest.NewRequest(http.MethodPost, "/deck/"+id+"/draw-cards?draw=1", nil)
+	request.Header.Set(api.ActorHeader, "dealer")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Unexpected status drawing cards. Expected: %+v, Got: %+v", http.StatusOK, recorder.Code)
+	}
+
+	var undone dto.UndoResponse
+	status := serve(t, router, http.MethodPost, "/deck/"+id+"/undo", &undone)
+
+	expectedUndone := dto.UndoResponse{DeckId: id, Remaining: 2, Undone: 1}
+
+	if status != http.StatusOK || !reflect.DeepEqual(undone, expectedUndone) {
+		t.Errorf("Unexpected response. Expected: %+v, Got: %+v with status %+v", expectedUndone, undone, status)
+	}
+
+	var history dto.HistoryResponse
+	serve(t, router, http.MethodGet, "/deck/"+id+"/history", &history)
+
+	var types, actors []string
+	var undoneFlags []bool
+	for _, event := range history.Events {
+		types = append(types, event.Type)
+		actors = append(actors, event.Actor)
+		undoneFlags = append(undoneFlags, event.Undone)
+	}
+
+	expectedTypes := []string{services.EventCreate, services.EventDraw, services.EventUndo}
+	expectedActors := []string{"", "dealer", ""}
+	expectedUndoneFlags := []bool{false, true, false}
+
+	if !reflect.DeepEqual(types, expectedTypes) || !reflect.DeepEqual(actors, expectedActors) || !reflect.DeepEqual(undoneFlags, expectedUndoneFlags) {
+		t.Errorf("Unexpected history: %+v", history)
+	}
+}
+
+func TestListDecks_FiltersAndPaginates(t *testing.T) {
+
+	router := createRouter()
+
+	first := createDeck(t, router, "?shuffle=false&labels=table:1,game:poker")
+	second := createDeck(t, router, "?shuffle=false&labels=table:2,game:poker")
+	createDeck(t, router, "?shuffle=true&labels=game:belote")
+
+	expected := []string{first, second}
+	sort.Strings(expected)
+
+	var page dto.ListDecksResponse
+	status := serve(t, router, http.MethodGet, "/decks?limit=1&shuffled=false&labels=game:poker&min_remaining=52", &page)
+	if status != http.StatusOK || len(page.Decks) != 1 || page.Decks[0].DeckId != expected[0] || page.NextCursor != expected[0] {
+		t.Fatalf("Unexpected first page with status %+v: %+v", status, page)
+	}
+
+	var next dto.ListDecksResponse
+	status = serve(t, router, http.MethodGet, "/decks?limit=1&shuffled=false&labels=game:poker&min_remaining=52&cursor="+page.NextCursor, &next)
+	if status != http.StatusOK || len(next.Decks) != 1 || next.Decks[0].DeckId != expected[1] || next.NextCursor != "" {
+		t.Fatalf("Unexpected second page with status %+v: %+v", status, next)
+	}
+
+	if next.Decks[0].Remaining != 52 || next.Decks[0].Labels["game"] != "poker" {
+		t.Errorf("Unexpected summary: %+v", next.Decks[0])
+	}
+}
+
+func TestDeleteDeck_ReleasesDeck(t *testing.T) {
+
+	router := createRouter()
+
+	id := createDeck(t, router, "")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/deck/"+id, nil))
+	if recorder.Code != http.StatusNoContent || recorder.Body.Len() != 0 {
+		t.Fatalf("Unexpected response deleting deck. Expected: %+v, Got: %+v %s", http.StatusNoContent, recorder.Code, recorder.Body.String())
+	}
+
+	var response dto.ErrorResponse
+	if status := serve(t, router, http.MethodGet, "/deck/"+id+"/open", &response); status != http.StatusNotFound {
+		t.Errorf("Unexpected status opening deleted deck. Expected: %+v, Got: %+v", http.StatusNotFound, status)
+	}
+}
+
+func TestCloseDeck_KeepsHistoryReadable(t *testing.T) {
+
+	router := createRouter()
+
+	id := createDeck(t, router, "?shuffle=false&cards=AC,2C")
+
+	var closed dto.CloseDeckResponse
+	status := serve(t, router, http.MethodPost, "/deck/"+id+"/close", &closed)
+
+	expectedClosed := dto.CloseDeckResponse{DeckId: id, Remaining: 2, Closed: true}
+
+	if status != http.StatusOK || !reflect.DeepEqual(closed, expectedClosed) {
+		t.Errorf("Unexpected response. Expected: %+v, Got: %+v with status %+v", expectedClosed, closed, status)
+	}
+
+	var history dto.HistoryResponse
+	status = serve(t, router, http.MethodGet, "/deck/"+id+"/history", &history)
+	if status != http.StatusOK || len(history.Events) != 2 || history.Events[1].Type != services.EventClose {
+		t.Errorf("Unexpected history of closed deck with status %+v: %+v", status, history)
+	}
+
+	var deck dto.OpenDeckResponse
+	status = serve(t, router, http.MethodGet, "/deck/"+id+"/open", &deck)
+	if status != http.StatusOK || !deck.Closed || deck.Remaining != 2 {
+		t.Errorf("Unexpected closed deck with status %+v: %+v", status, deck)
+	}
+}
+
+func TestCloneDeck_CreatesIndependentDeck(t *testing.T) {
+
+	router := createRouter()
+
+	id := createDeck(t, router, "?shuffle=false&cards=AC,2C,3C")
+	serve(t, router, http.MethodPost, "/deck/"+id+"/draw-cards?draw=1", &dto.DrawCardsResponse{})
+
+	var cloned dto.CloneDeckResponse
+	status := serve(t, router, http.MethodPost, "/deck/"+id+"/clone", &cloned)
+
+	expectedCloned := dto.CloneDeckResponse{DeckId: cloned.DeckId, SourceDeckId: id, Remaining: 2, Type: "standard"}
+
+	if status != http.StatusOK || cloned.DeckId == id || !reflect.DeepEqual(cloned, expectedCloned) {
+		t.Fatalf("Unexpected response. Expected: %+v, Got: %+v with status %+v", expectedCloned, cloned, status)
+	}
+
+	serve(t, router, http.MethodPost, "/deck/"+cloned.DeckId+"/draw-cards?draw=2", &dto.DrawCardsResponse{})
+
+	var source dto.OpenDeckResponse
+	status = serve(t, router, http.MethodGet, "/deck/"+id+"/open", &source)
+	if status != http.StatusOK || source.Remaining != 2 {
+		t.Errorf("Unexpected source deck after drawing from clone with status %+v: %+v", status, source)
+	}
+}
+
+func TestAdmin_ExportsAndImportsDecks(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	service := services.NewDecksService(createMockDecksConfiguration(), services.NewDecksInMemoryStore())
+	api.NewHandlersWithAdminToken(service, "secret").SetupRoutes(router)
+
+	id := createDeck(t, router, "?shuffle=false&cards=AC,2C")
+
+	// Admin routes are rejected without the token, and disabled without a configured token
+	var response dto.ErrorResponse
+	if status := serve(t, router, http.MethodGet, "/admin/export", &response); status != http.StatusUnauthorized || response.Code != "unauthorized" {
+		t.Errorf("Unexpected response. Expected: %+v, Got: %+v with status %+v", "unauthorized", response, status)
+	}
+	disabled := createRouter()
+	request := httptest.NewRequest(http.MethodGet, "/admin/export", nil)
+	request.Header.Set("Authorization", "Bearer ")
+	recorder := httptest.NewRecorder()
+	disabled.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status. Expected: %+v, Got: %+v", http.StatusUnauthorized, recorder.Code)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/admin/export?format=gzip", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/gzip" {
+		t.Fatalf("Unexpected export. Status: %+v, headers: %+v", recorder.Code, recorder.Header())
+	}
+
+	// The snapshot is imported into another instance
+	importing := gin.New()
+	api.NewHandlersWithAdminToken(services.NewDecksService(createMockDecksConfiguration(), services.NewDecksInMemoryStore()), "secret").SetupRoutes(importing)
+
+	request = httptest.NewRequest(http.MethodPost, "/admin/import", recorder.Body)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder = httptest.NewRecorder()
+	importing.ServeHTTP(recorder, request)
+
+	var imported dto.ImportDecksResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &imported); err != nil || imported.Imported != 1 {
+		t.Errorf("Unexpected import: %s", recorder.Body.String())
+	}
+
+	var deck dto.OpenDeckResponse
+	serve(t, importing, http.MethodGet, "/deck/"+id+"/open", &deck)
+
+	if deck.DeckId != id || deck.Remaining != 2 {
+		t.Errorf("Unexpected imported deck: %+v", deck)
+	}
+}
@@ -0,0 +1,247 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+)
+
+// Serves a request with a JSON body and decodes the JSON response body into result
+func serveJSON(t *testing.T, router *gin.Engine, method string, url string, body string, result any) int {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, url, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+	if err := json.Unmarshal(recorder.Body.Bytes(), result); err != nil {
+		t.Errorf("Unexpected error decoding %s: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code
+}
+
+// Returns the codes of cards
+func codesOf(cards []dto.CardDto) []string {
+	var codes []string
+	for _, card := range cards {
+		codes = append(codes, card.Code)
+	}
+	return codes
+}
+
+func TestV2_ChangesDeckAndRespondsWithState(t *testing.T) {
+
+	router := createRouter()
+
+	var created dto.DeckStateResponse
+	status := serveJSON(t, router, http.MethodPost, "/v2/decks", `{"shuffle": false, "cards": ["AC", "2C", "3C"], "labels": {"table": "1"}}`, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("Unexpected status creating deck. Expected: %+v, Got: %+v", http.StatusCreated, status)
+	}
+	id := created.Deck.DeckId
+	if created.Deck.Remaining != 3 || created.Deck.Drawn != 0 || created.Deck.Shuffled || created.Deck.Labels["table"] != "1" || created.Cards != nil {
+		t.Errorf("Unexpected created deck: %+v", created)
+	}
+
+	var drawn dto.DeckStateResponse
+	status = serveJSON(t, router, http.MethodPost, "/v2/decks/"+id+"/draw", `{"count": 2}`, &drawn)
+	if status != http.StatusOK || !reflect.DeepEqual(codesOf(drawn.Cards), []string{"AC", "2C"}) || drawn.Deck.Remaining != 1 || drawn.Deck.Drawn != 2 {
+		t.Errorf("Unexpected response drawing cards with status %+v: %+v", status, drawn)
+	}
+
+	var returned dto.DeckStateResponse
+	status = serveJSON(t, router, http.MethodPost, "/v2/decks/"+id+"/return", `{"cards": ["AC"], "position": "bottom"}`, &returned)
+	if status != http.StatusOK || !reflect.DeepEqual(codesOf(returned.Cards), []string{"AC"}) || returned.Deck.Remaining != 2 || returned.Deck.Drawn != 1 {
+		t.Errorf("Unexpected response returning cards with status %+v: %+v", status, returned)
+	}
+
+	var deck dto.DeckStateResponse
+	status = serveJSON(t, router, http.MethodGet, "/v2/decks/"+id, "", &deck)
+	if status != http.StatusOK || !reflect.DeepEqual(codesOf(deck.Cards), []string{"3C", "AC"}) {
+		t.Errorf("Unexpected deck with status %+v: %+v", status, deck)
+	}
+
+	var shuffled dto.DeckStateResponse
+	status = serveJSON(t, router, http.MethodPost, "/v2/decks/"+id+"/shuffle", `{"seed": 3}`, &shuffled)
+	if status != http.StatusOK || !shuffled.Deck.Shuffled || shuffled.Deck.Seed == nil || *shuffled.Deck.Seed != 3 {
+		t.Errorf("Unexpected response shuffling deck with status %+v: %+v", status, shuffled)
+	}
+
+	// An empty body is an empty request
+	var random dto.DeckStateResponse
+	status = serveJSON(t, router, http.MethodPost, "/v2/decks/"+id+"/shuffle", "", &random)
+	if status != http.StatusOK || random.Deck.Seed != nil {
+		t.Errorf("Unexpected response shuffling deck with status %+v: %+v", status, random)
+	}
+}
+
+func TestV2_RejectsInvalidBodies(t *testing.T) {
+
+	router := createRouter()
+
+	var created dto.DeckStateResponse
+	serveJSON(t, router, http.MethodPost, "/v2/decks", `{"shuffle": false}`, &created)
+	draw := "/v2/decks/" + created.Deck.DeckId + "/draw"
+
+	tests := []struct {
+		name             string
+		url              string
+		body             string
+		expectedResponse dto.ErrorResponse
+	}{
+		{
+			name: "unknown field",
+			url:  "/v2/decks",
+			body: `{"shufle": true}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "unknown field: shufle",
+				Field:   "shufle",
+			},
+		},
+		{
+			name: "string instead of boolean",
+			url:  "/v2/decks",
+			body: `{"shuffle": "yes"}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "invalid type for shuffle: expected bool, got string",
+				Field:   "shuffle",
+			},
+		},
+		{
+			name: "deck count out of range",
+			url:  "/v2/decks",
+			body: `{"deck_count": 0}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "deck count must be between 1 and 100, got: 0",
+				Field:   "deck_count",
+			},
+		},
+		{
+			name: "empty card list",
+			url:  "/v2/decks",
+			body: `{"cards": []}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "the list of cards must not be empty",
+				Field:   "cards",
+			},
+		},
+		{
+			name: "card list of numbers",
+			url:  "/v2/decks",
+			body: `{"cards": [10]}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "invalid type for cards.0: expected string, got number",
+				Field:   "cards.0",
+			},
+		},
+		{
+			name: "bad card code",
+			url:  "/v2/decks",
+			body: `{"cards": ["AC", "10C"]}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_card_code",
+				Message: "invalid card code: 10C",
+				Field:   "cards",
+			},
+		},
+		{
+			name: "card not in deck type",
+			url:  "/v2/decks",
+			body: `{"cards": ["AC", "ZZ"]}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_card_code",
+				Message: "card ZZ is not in deck type standard",
+				Field:   "cards",
+			},
+		},
+		{
+			name: "several JSON values",
+			url:  "/v2/decks",
+			body: `{} {}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "the request body must hold a single JSON object",
+				Field:   "body",
+			},
+		},
+		{
+			name: "malformed JSON",
+			url:  "/v2/decks",
+			body: `{"shuffle": }`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "invalid JSON request body: invalid character '}' looking for beginning of value",
+				Field:   "body",
+			},
+		},
+		{
+			name: "nothing to draw",
+			url:  draw,
+			body: `{}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "either count or cards is required",
+				Field:   "count",
+			},
+		},
+		{
+			name: "count and cards to draw",
+			url:  draw,
+			body: `{"count": 1, "cards": ["AC"]}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "either count or cards can be given, not both",
+				Field:   "cards",
+			},
+		},
+		{
+			name: "count out of range",
+			url:  draw,
+			body: `{"count": 0}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "count must be at least 1, got: 0",
+				Field:   "count",
+			},
+		},
+		{
+			name: "index without index position",
+			url:  draw,
+			body: `{"count": 1, "index": 3}`,
+			expectedResponse: dto.ErrorResponse{
+				Code:    "invalid_parameter",
+				Message: "an index can only be given with the index position",
+				Field:   "index",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var response dto.ErrorResponse
+		status := serveJSON(t, router, http.MethodPost, test.url, test.body, &response)
+
+		if status != http.StatusBadRequest {
+			t.Errorf("Unexpected status for %s. Expected: %+v, Got: %+v", test.name, http.StatusBadRequest, status)
+		}
+
+		if !reflect.DeepEqual(response, test.expectedResponse) {
+			t.Errorf("Unexpected response for %s. Expected: %+v, Got: %+v", test.name, test.expectedResponse, response)
+		}
+	}
+
+	// No invalid request changed the deck
+	var deck dto.DeckStateResponse
+	serveJSON(t, router, http.MethodGet, "/v2/decks/"+created.Deck.DeckId, "", &deck)
+	if deck.Deck.Remaining != 52 {
+		t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", 52, deck.Deck.Remaining)
+	}
+}
@@ -0,0 +1,99 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// The parts of the OpenAPI document that are checked
+type openAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]any `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	Summary    string                      `json:"summary"`
+	Parameters []struct{ Name, In string } `json:"parameters"`
+	Responses  map[string]any              `json:"responses"`
+}
+
+func TestOpenAPI_DescribesAllRoutes(t *testing.T) {
+
+	router := createRouter()
+
+	var document openAPIDocument
+	if status := serve(t, router, http.MethodGet, "/openapi.json", &document); status != http.StatusOK {
+		t.Fatalf("Unexpected status. Expected: %+v, Got: %+v", http.StatusOK, status)
+	}
+	if !strings.HasPrefix(document.OpenAPI, "3.") {
+		t.Errorf("Unexpected OpenAPI version: %+v", document.OpenAPI)
+	}
+
+	described := 0
+	for _, route := range router.Routes() {
+		path := regexp.MustCompile(`:(\w+)`).ReplaceAllString(route.Path, "{$1}")
+		operation, ok := document.Paths[path][strings.ToLower(route.Method)]
+		if !ok {
+			t.Errorf("Route is not described: %s %s", route.Method, route.Path)
+			continue
+		}
+		described++
+		if operation.Summary == "" || operation.Responses["default"] == nil {
+			t.Errorf("Unexpected description of %s %s: %+v", route.Method, route.Path, operation)
+		}
+		for _, name := range regexp.MustCompile(`:(\w+)`).FindAllStringSubmatch(route.Path, -1) {
+			found := false
+			for _, parameter := range operation.Parameters {
+				found = found || (parameter.Name == name[1] && parameter.In == "path")
+			}
+			if !found {
+				t.Errorf("Path parameter %s of %s %s is not described", name[1], route.Method, route.Path)
+			}
+		}
+	}
+
+	// Nor are routes described that are not set up
+	operations := 0
+	for _, methods := range document.Paths {
+		operations += len(methods)
+	}
+	if operations != described {
+		t.Errorf("Unexpected number of described routes. Expected: %+v, Got: %+v", described, operations)
+	}
+
+	for _, name := range []string{"ErrorResponse", "CreateDeckResponse", "CardDto", "DeckStateResponse", "CreateDeckRequest"} {
+		if document.Components.Schemas[name] == nil {
+			t.Errorf("Schema is not described: %s", name)
+		}
+	}
+}
+
+func TestOpenAPI_ServesDocumentationPage(t *testing.T) {
+
+	router := createRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "/openapi.json") {
+		t.Errorf("Unexpected documentation page with status %+v: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// The page only loads assets served by the API itself
+	if strings.Contains(recorder.Body.String(), "https://") {
+		t.Errorf("Documentation page loads assets from another site: %s", recorder.Body.String())
+	}
+	for _, asset := range []string{"/docs/swagger-ui.css", "/docs/swagger-ui-bundle.js"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, asset, nil))
+		if recorder.Code != http.StatusOK || recorder.Body.Len() == 0 || !strings.Contains(recorder.Body.String(), "swagger-ui") {
+			t.Errorf("Unexpected asset %s with status %+v", asset, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rnkjnk/decks-api/client"
+	"github.com/rnkjnk/decks-api/internal/api"
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+const adminToken = "secret"
+
+// We will test with a hard-coded standard deck, so config file modifications don't spoil tests
+func createMockDecksConfiguration() configs.DecksConfig {
+	decksConfig := configs.DecksConfig{
+		Suits:  []string{"CLUBS", "DIAMONDS", "HEARTS", "SPADES"},
+		Values: []string{"ACE", "2", "3", "4", "5", "6", "7", "8", "9", "TEN", "JACK", "QUEEN", "KING"},
+	}
+	return decksConfig
+}
+
+// Starts a server with all routes, backed by an in-memory repository, serving its requests through wrap
+func startServer(t *testing.T, wrap func(http.Handler) http.Handler) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	service := services.NewDecksService(createMockDecksConfiguration(), services.NewDecksInMemoryStore())
+	api.NewHandlersWithAdminToken(service, adminToken).SetupRoutes(router)
+
+	server := httptest.NewServer(wrap(router))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Serves requests unchanged
+func unchanged(handler http.Handler) http.Handler {
+	return handler
+}
+
+// Responds with the given status to the first requests, then serves the next ones, counting all requests
+func failFirst(failures int, status int, requests *atomic.Int32) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) <= int32(failures) {
+				w.WriteHeader(status)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Returns the codes of cards
+func codesOf(cards []client.CardDto) []string {
+	var codes []string
+	for _, card := range cards {
+		codes = append(codes, card.Code)
+	}
+	return codes
+}
+
+// Returns a client with short waits between attempts
+func newClient(server *httptest.Server) *client.DecksClient {
+	return client.NewDecksClientWithOptions(server.URL, client.Options{
+		AdminToken: adminToken,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+	})
+}
+
+func TestDecksClient_CallsRoutes(t *testing.T) {
+
+	decks := newClient(startServer(t, unchanged))
+	ctx := context.Background()
+
+	shuffle := false
+	created, err := decks.CreateDeck(ctx, client.CreateDeckRequest{
+		Shuffle: &shuffle,
+		Cards:   []string{"AC", "2C", "3C", "4C"},
+		Labels:  map[string]string{"table": "1"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	deckId := created.Deck.DeckId
+
+	count := 2
+	drawn, err := decks.DrawCards(ctx, deckId, client.DrawCardsRequest{Count: &count})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(codesOf(drawn.Cards), []string{"AC", "2C"}) || drawn.Deck.Remaining != 2 {
+		t.Errorf("Unexpected drawn cards: %+v", drawn)
+	}
+
+	if _, err := decks.WithActor("alice").ReturnCards(ctx, deckId, client.ReturnCardsRequest{Cards: []string{"2C"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	opened, err := decks.OpenDeck(ctx, deckId)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(codesOf(opened.Cards), []string{"2C", "3C", "4C"}) {
+		t.Errorf("Unexpected opened deck: %+v", opened)
+	}
+
+	history, err := decks.History(ctx, deckId)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actor := history.Events[len(history.Events)-1].Actor; actor != "alice" {
+		t.Errorf("Unexpected actor. Expected: %+v, Got: %+v", "alice", actor)
+	}
+
+	pile, err := decks.AddToPile(ctx, deckId, "discard", 1, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(codesOf(pile.Cards), []string{"2C"}) {
+		t.Errorf("Unexpected pile: %+v", pile)
+	}
+
+	listed, err := decks.ListDecks(ctx, client.ListDecksOptions{Labels: map[string]string{"table": "1"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(listed.Decks) != 1 || listed.Decks[0].DeckId != deckId {
+		t.Errorf("Unexpected listed decks: %+v", listed)
+	}
+
+	// A snapshot of the decks is imported back as it was exported
+	snapshot, err := decks.ExportDecks(ctx, "jsonl")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := io.ReadAll(snapshot)
+	snapshot.Close()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := decks.DeleteDeck(ctx, deckId); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var apiError *client.Error
+	if _, err := decks.OpenDeck(ctx, deckId); !errors.As(err, &apiError) || apiError.Status != http.StatusNotFound || apiError.Code != "not_found" {
+		t.Errorf("Unexpected error opening deleted deck: %v", err)
+	}
+
+	imported, err := decks.ImportDecks(ctx, bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if imported.Imported != 1 {
+		t.Errorf("Unexpected imported decks. Expected: %+v, Got: %+v", 1, imported.Imported)
+	}
+	if _, err := decks.OpenDeck(ctx, deckId); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestDecksClient_ReturnsErrorBodies(t *testing.T) {
+
+	decks := newClient(startServer(t, unchanged))
+	ctx := context.Background()
+
+	created, err := decks.CreateDeck(ctx, client.CreateDeckRequest{Cards: []string{"AC"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	count := 2
+	deck, err := decks.DrawCards(ctx, created.Deck.DeckId, client.DrawCardsRequest{Count: &count})
+	if deck != nil {
+		t.Errorf("Unexpected response with error: %+v", deck)
+	}
+
+	expected := &client.Error{
+		Status: http.StatusConflict,
+		ErrorResponse: client.ErrorResponse{
+			Code:    "insufficient_cards",
+			Message: "2 card(s) requested, but deck id " + created.Deck.DeckId + " has only 1 card(s) left",
+			Field:   "draw",
+		},
+	}
+	var apiError *client.Error
+	if !errors.As(err, &apiError) || !reflect.DeepEqual(apiError, expected) {
+		t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expected, err)
+	}
+}
+
+func TestDecksClient_RetriesIdempotentCalls(t *testing.T) {
+
+	var requests atomic.Int32
+	server := startServer(t, failFirst(2, http.StatusServiceUnavailable, &requests))
+	decks := newClient(server)
+
+	types, err := decks.ListDeckTypes(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(types.Types) != 1 || requests.Load() != 3 {
+		t.Errorf("Unexpected deck types after %d requests: %+v", requests.Load(), types)
+	}
+
+	// Calls that change decks are not retried
+	requests.Store(0)
+	_, err = decks.CreateDeck(context.Background(), client.CreateDeckRequest{})
+	var apiError *client.Error
+	if !errors.As(err, &apiError) || apiError.Status != http.StatusServiceUnavailable || requests.Load() != 1 {
+		t.Errorf("Unexpected error creating deck after %d requests: %v", requests.Load(), err)
+	}
+
+	// Nor are calls that failed because of the request
+	requests.Store(2)
+	if _, err := decks.OpenDeck(context.Background(), "not-a-uuid"); !errors.As(err, &apiError) || apiError.Code != "invalid_id" || requests.Load() != 3 {
+		t.Errorf("Unexpected error opening deck after %d requests: %v", requests.Load()-2, err)
+	}
+
+	// The number of retries is limited
+	retries := 1
+	requests.Store(0)
+	once := client.NewDecksClientWithOptions(server.URL, client.Options{Retries: &retries, MinBackoff: time.Millisecond})
+	if _, err := once.ListDeckTypes(context.Background()); !errors.As(err, &apiError) || apiError.Status != http.StatusServiceUnavailable || requests.Load() != 2 {
+		t.Errorf("Unexpected error after %d requests: %v", requests.Load(), err)
+	}
+}
+
+func TestDecksClient_RetriedDeletionSucceedsIfDeckIsGone(t *testing.T) {
+
+	// The first deletion is done, but its response is lost
+	var requests atomic.Int32
+	server := startServer(t, func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodDelete && requests.Add(1) == 1 {
+				handler.ServeHTTP(httptest.NewRecorder(), r)
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+	})
+	decks := newClient(server)
+
+	created, err := decks.CreateDeck(context.Background(), client.CreateDeckRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := decks.DeleteDeck(context.Background(), created.Deck.DeckId); err != nil || requests.Load() != 2 {
+		t.Errorf("Unexpected error after %d requests: %v", requests.Load(), err)
+	}
+
+	// Without a retry, a deck that is not found is still an error
+	var apiError *client.Error
+	if err := decks.DeleteDeck(context.Background(), created.Deck.DeckId); !errors.As(err, &apiError) || apiError.Code != "not_found" {
+		t.Errorf("Unexpected error. Expected: %+v, Got: %+v", "not_found", err)
+	}
+}
+
+func TestDecksClient_StopsWhenContextIsCanceled(t *testing.T) {
+
+	// Requests listing deck types are held until the client gives up
+	server := startServer(t, func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/deck-types" {
+				<-r.Context().Done()
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+	})
+	decks := newClient(server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := decks.ListDeckTypes(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error. Expected: %+v, Got: %+v", context.DeadlineExceeded, err)
+	}
+
+	// Waiting between attempts stops as well
+	var requests atomic.Int32
+	failing := startServer(t, failFirst(100, http.StatusServiceUnavailable, &requests))
+	slow := client.NewDecksClientWithOptions(failing.URL, client.Options{MinBackoff: time.Minute, MaxBackoff: time.Minute})
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if _, err := slow.ListDeckTypes(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error. Expected: %+v, Got: %+v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second || requests.Load() != 1 {
+		t.Errorf("Unexpected wait for canceled call of %v, after %d requests", elapsed, requests.Load())
+	}
+}
@@ -0,0 +1,113 @@
+package fairness_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/fairness"
+)
+
+// A server seed of 32 zero bytes
+var zeroSeed = strings.Repeat("00", fairness.SeedSize)
+
+var cards = []string{"AC", "2C", "3C", "4C", "5C", "6C", "7C", "8C", "9C", "TC"}
+
+func TestCommit_HashesSeedBytes(t *testing.T) {
+
+	commitment, err := fairness.Commit(zeroSeed)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expected := "66687aadf862bd776c8fc18b8e9f8e20089714856ee233b3902a591d0d5f2925"
+
+	if commitment != expected {
+		t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expected, commitment)
+	}
+}
+
+func TestShuffle_OrderIsPinned(t *testing.T) {
+
+	// The algorithm is documented for players to reimplement, so its output must never change
+	shuffled, err := fairness.Shuffle(zeroSeed, "player", cards)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"2C", "4C", "3C", "9C", "6C", "AC", "TC", "7C", "8C", "5C"}
+
+	if !reflect.DeepEqual(shuffled, expected) {
+		t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expected, shuffled)
+	}
+}
+
+func TestPermutation_UsesEveryPositionOnce(t *testing.T) {
+
+	serverSeed, err := fairness.NewServerSeed()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	permutation, err := fairness.Permutation(serverSeed, "player", 416)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sorted := append([]int{}, permutation...)
+	sort.Ints(sorted)
+	for i, position := range sorted {
+		if position != i {
+			t.Fatalf("Unexpected permutation: %+v", permutation)
+		}
+	}
+}
+
+func TestVerify_AcceptsShuffleWithSeeds(t *testing.T) {
+
+	serverSeed, err := fairness.NewServerSeed()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	commitment, err := fairness.Commit(serverSeed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	shuffled, err := fairness.Shuffle(serverSeed, "player", cards)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := fairness.Verify(serverSeed, commitment, "player", cards, shuffled); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestVerify_RejectsMismatches(t *testing.T) {
+
+	commitment, _ := fairness.Commit(zeroSeed)
+	shuffled, _ := fairness.Shuffle(zeroSeed, "player", cards)
+	otherSeed := strings.Repeat("01", fairness.SeedSize)
+
+	tests := map[string]struct {
+		serverSeed    string
+		clientSeed    string
+		shuffled      []string
+		expectedError error
+	}{
+		"other server seed": {otherSeed, "player", shuffled, fairness.ErrCommitmentMismatch},
+		"other client seed": {zeroSeed, "dealer", shuffled, fairness.ErrOrderMismatch},
+		"other order":       {zeroSeed, "player", cards, fairness.ErrOrderMismatch},
+		"missing cards":     {zeroSeed, "player", shuffled[1:], fairness.ErrOrderMismatch},
+		"malformed seed":    {"not hex", "player", shuffled, fairness.ErrInvalidSeed},
+	}
+
+	for name, test := range tests {
+		err := fairness.Verify(test.serverSeed, commitment, test.clientSeed, cards, test.shuffled)
+		if !errors.Is(err, test.expectedError) {
+			t.Errorf("Unexpected error for %s. Expected: %+v, Got: %+v", name, test.expectedError, err)
+		}
+	}
+}
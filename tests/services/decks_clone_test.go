@@ -0,0 +1,215 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestCloneDeck_CopiesDrawnRemainingAndPiles(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		sourceId := createDeckWithTwoDrawn(t, service)
+		if _, err := service.AddToPile(sourceId, "discard", 0, [][2]rune{{'A', 'C'}}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		cloned, err := service.CloneDeck(sourceId, services.CloneOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cloned.DeckId == sourceId || cloned.SourceDeckId != sourceId || cloned.Remaining != 2 ||
+			!reflect.DeepEqual(cloned.Piles, map[string]int{"discard": 1}) {
+			t.Errorf("Unexpected clone: %+v", cloned)
+		}
+
+		source, err := store.Get(uuid.MustParse(sourceId))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		clone, err := store.Get(uuid.MustParse(cloned.DeckId))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(clone.Cards, source.Cards) || clone.Remaining != source.Remaining || !reflect.DeepEqual(clone.Piles, source.Piles) {
+			t.Errorf("Unexpected clone. Expected: %+v, Got: %+v", source, clone)
+		}
+
+		// The clone has its own history, and changes independently of the source
+		if types := historyTypes(t, service, cloned.DeckId); !reflect.DeepEqual(types, []string{services.EventCreate}) {
+			t.Errorf("Unexpected history of clone. Expected: %+v, Got: %+v", []string{services.EventCreate}, types)
+		}
+		if _, err := service.DrawCards(cloned.DeckId, services.DrawOptions{Count: 2}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.ReturnPile(cloned.DeckId, "discard", ""); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if codes := remainingCodes(t, service, sourceId); !reflect.DeepEqual(codes, []string{"3C", "4C"}) {
+			t.Errorf("Unexpected remaining cards of source. Expected: %+v, Got: %+v", []string{"3C", "4C"}, codes)
+		}
+		if _, err := service.Undo(cloned.DeckId, 2); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if codes := remainingCodes(t, service, cloned.DeckId); !reflect.DeepEqual(codes, []string{"3C", "4C"}) {
+			t.Errorf("Unexpected remaining cards of clone after undo. Expected: %+v, Got: %+v", []string{"3C", "4C"}, codes)
+		}
+	})
+}
+
+func TestCloneDeck_ReshufflesOnlyRemainingCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		seed := int64(42)
+		var clones [][]string
+		for i := 0; i < 2; i++ {
+			cloned, err := service.CloneDeck(created.DeckId, services.CloneOptions{Reshuffle: true, Seed: &seed})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !cloned.Shuffled || !cloned.Reshuffled || cloned.Seed == nil || *cloned.Seed != seed {
+				t.Errorf("Unexpected clone: %+v", cloned)
+			}
+			deck, err := store.Get(uuid.MustParse(cloned.DeckId))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(deck.Cards[:2], cardsOf("AC", "2C")) {
+				t.Errorf("Unexpected drawn cards of clone. Expected: %+v, Got: %+v", cardsOf("AC", "2C"), deck.Cards[:2])
+			}
+			clones = append(clones, remainingCodes(t, service, cloned.DeckId))
+		}
+
+		source := remainingCodes(t, service, created.DeckId)
+		if reflect.DeepEqual(clones[0], source) {
+			t.Errorf("Remaining cards of clone were not reshuffled: %+v", clones[0])
+		}
+		if !reflect.DeepEqual(clones[0], clones[1]) {
+			t.Errorf("Unexpected order of clones reshuffled with the same seed. Expected: %+v, Got: %+v", clones[0], clones[1])
+		}
+		sorted := append([]string{}, clones[0]...)
+		sort.Strings(sorted)
+		sort.Strings(source)
+		if !reflect.DeepEqual(sorted, source) {
+			t.Errorf("Unexpected remaining cards of clone. Expected: %+v, Got: %+v", source, sorted)
+		}
+	})
+}
+
+func TestCloneDeck_ErrorIfHiddenWithoutReshuffle(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		hidden, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, Hidden: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		fair, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, Fair: true, ClientSeed: "player"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		seed := int64(7)
+
+		tests := []struct {
+			name         string
+			deckId       string
+			options      services.CloneOptions
+			expectedKind error
+		}{
+			{"hidden", hidden.DeckId, services.CloneOptions{}, services.ErrInvalidParameter},
+			{"seed without reshuffle", fair.DeckId, services.CloneOptions{Seed: &seed}, services.ErrInvalidParameter},
+			{"unknown deck", "5b25d675-b285-4713-b976-9571a404f88a", services.CloneOptions{}, services.ErrNotFound},
+		}
+		for _, test := range tests {
+			if _, err := service.CloneDeck(test.deckId, test.options); !errors.Is(err, test.expectedKind) {
+				t.Errorf("Unexpected error for %s. Expected: %+v, Got: %+v", test.name, test.expectedKind, err)
+			}
+		}
+
+		for _, test := range []struct {
+			deckId  string
+			options services.CloneOptions
+		}{
+			{hidden.DeckId, services.CloneOptions{Reshuffle: true}},
+			{fair.DeckId, services.CloneOptions{Reshuffle: true}},
+			// The cards of a provably fair deck can be opened, so it can be cloned as it is
+			{fair.DeckId, services.CloneOptions{}},
+		} {
+			cloned, err := service.CloneDeck(test.deckId, test.options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// The server seed of the source is not copied, so it cannot be revealed through the clone
+			if _, err := service.VerifyDeck(cloned.DeckId); !errors.Is(err, services.ErrNotProvablyFair) {
+				t.Errorf("Unexpected error verifying clone. Expected: %+v, Got: %+v", services.ErrNotProvablyFair, err)
+			}
+		}
+	})
+}
+
+func TestCloneDeck_AtomicWithConcurrentDraws(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		full, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		all := codesOf(full.Cards)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 12; j++ {
+					if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 1}); err != nil {
+						t.Errorf("Unexpected error: %v", err)
+					}
+				}
+			}()
+		}
+
+		clones := make(chan string, 20)
+		for i := 0; i < 20; i++ {
+			cloned, err := service.CloneDeck(created.DeckId, services.CloneOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			clones <- cloned.DeckId
+		}
+		wg.Wait()
+		close(clones)
+
+		// Each clone holds the source as it was between two draws
+		for deckId := range clones {
+			codes := remainingCodes(t, service, deckId)
+			if expected := all[len(all)-len(codes):]; !reflect.DeepEqual(codes, expected) {
+				t.Errorf("Unexpected remaining cards of clone. Expected: %+v, Got: %+v", expected, codes)
+			}
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestDeal_DealsRoundRobinAfterBurning(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		response, err := service.Deal(created.DeckId, []string{"alice", "bob", "carol"}, 2, 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedResponse := &dto.DealResponse{
+			DeckId:    created.DeckId,
+			Remaining: 45,
+			Burned:    []dto.CardDto{{Suit: "CLUBS", Value: "ACE", Code: "AC"}},
+			Hands: []dto.HandDto{
+				{Player: "alice", Cards: []dto.CardDto{{Suit: "CLUBS", Value: "2", Code: "2C"}, {Suit: "CLUBS", Value: "5", Code: "5C"}}},
+				{Player: "bob", Cards: []dto.CardDto{{Suit: "CLUBS", Value: "3", Code: "3C"}, {Suit: "CLUBS", Value: "6", Code: "6C"}}},
+				{Player: "carol", Cards: []dto.CardDto{{Suit: "CLUBS", Value: "4", Code: "4C"}, {Suit: "CLUBS", Value: "7", Code: "7C"}}},
+			},
+		}
+
+		if !reflect.DeepEqual(response, expectedResponse) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedResponse, response)
+		}
+
+		// Hands are kept as piles, and can be fetched later
+		hand, err := service.ListPile(created.DeckId, "bob")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(hand.Cards, expectedResponse.Hands[1].Cards) {
+			t.Errorf("Unexpected hand. Expected: %+v, Got: %+v", expectedResponse.Hands[1].Cards, hand.Cards)
+		}
+
+		// Burned cards are drawn, and can be returned to the deck
+		returned, err := service.ReturnCards(created.DeckId, services.ReturnOptions{Cards: [][2]rune{{'A', 'C'}}, Position: services.PositionTop})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if returned.Remaining != 46 {
+			t.Errorf("Unexpected remaining count. Expected: %+v, Got: %+v", 46, returned.Remaining)
+		}
+	})
+}
+
+func TestDeal_ErrorIfPlayerNamedLikePile(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.Deal(created.DeckId, []string{"alice", "bob"}, 1, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		_, err = service.AddToPile(created.DeckId, "discard", 1, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for _, player := range []string{"bob", "discard"} {
+			_, err = service.Deal(created.DeckId, []string{"carol", player}, 1, 0)
+
+			expectedError := "player " + player + " is named like a pile of deck id " + created.DeckId
+
+			if err == nil {
+				t.Errorf("Expected error was not returned: %s", expectedError)
+			} else if expectedError != err.Error() {
+				t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err.Error())
+			} else if !errors.Is(err, services.ErrInvalidParameter) {
+				t.Errorf("Unexpected error kind. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+			}
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if deck.Remaining != 49 || len(deck.Piles) != 3 {
+			t.Errorf("Deck was modified by a failed deal: %+v", deck)
+		}
+	})
+}
+
+func TestDeal_DealsNothingIfCardsAreInsufficient(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}, {'4', 'C'}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.Deal(created.DeckId, []string{"alice", "bob"}, 2, 1)
+
+		expectedError := "5 card(s) needed to deal, but deck id " + created.DeckId + " has only 4 card(s) left"
+
+		if err == nil {
+			t.Errorf("Expected error was not returned: %s", expectedError)
+		} else if expectedError != err.Error() {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err.Error())
+		} else if !errors.Is(err, services.ErrInsufficientCards) {
+			t.Errorf("Unexpected error kind. Expected: %+v, Got: %+v", services.ErrInsufficientCards, err)
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if deck.Remaining != 4 || deck.Piles != nil {
+			t.Errorf("Deck was modified by a failed deal: %+v", deck)
+		}
+	})
+}
+
+func TestDeal_ErrorIfParametersInvalid(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		tests := map[string]struct {
+			players       []string
+			count         int
+			burn          int
+			expectedField string
+		}{
+			"no players":       {nil, 1, 0, "players"},
+			"empty player":     {[]string{"alice", ""}, 1, 0, "players"},
+			"duplicate player": {[]string{"alice", "alice"}, 1, 0, "players"},
+			"no cards":         {[]string{"alice"}, 0, 0, "count"},
+			"negative burn":    {[]string{"alice"}, 1, -1, "burn"},
+		}
+
+		for name, test := range tests {
+			_, err := service.Deal(created.DeckId, test.players, test.count, test.burn)
+
+			var serviceError *services.Error
+			if !errors.As(err, &serviceError) || serviceError.Kind != services.ErrInvalidParameter || serviceError.Field != test.expectedField {
+				t.Errorf("Unexpected error for %s: %+v", name, err)
+			}
+		}
+	})
+}
+
+func TestDeal_ConcurrentDealsNeverDealSameCard(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var mu sync.Mutex
+		dealt := make(map[string]int)
+
+		// Each table deals 2 cards to each of 2 players, so 13 of them use up the deck
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(table int) {
+				defer wg.Done()
+				players := []string{string(rune('a'+table)) + "1", string(rune('a'+table)) + "2"}
+				response, err := service.Deal(created.DeckId, players, 2, 0)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, hand := range response.Hands {
+					for _, card := range hand.Cards {
+						dealt[card.Code]++
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if len(dealt) != 52 {
+			t.Errorf("Unexpected number of distinct cards dealt. Expected: %+v, Got: %+v", 52, len(dealt))
+		}
+
+		for code, count := range dealt {
+			if count != 1 {
+				t.Errorf("Card %s was dealt %d times", code, count)
+			}
+		}
+	})
+}
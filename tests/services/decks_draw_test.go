@@ -0,0 +1,189 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestDrawCards_DrawsFromPositions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		tests := []struct {
+			name              string
+			options           services.DrawOptions
+			expectedDrawn     []string
+			expectedRemaining []string
+		}{
+			{
+				name:              "top",
+				options:           services.DrawOptions{Count: 2},
+				expectedDrawn:     []string{"AC", "2C"},
+				expectedRemaining: []string{"3C", "4C", "5C"},
+			},
+			{
+				name:              "bottom",
+				options:           services.DrawOptions{Count: 2, Position: services.PositionBottom},
+				expectedDrawn:     []string{"5C", "4C"},
+				expectedRemaining: []string{"AC", "2C", "3C"},
+			},
+			{
+				name:              "index",
+				options:           services.DrawOptions{Count: 2, Position: services.PositionIndex, Index: 1},
+				expectedDrawn:     []string{"2C", "3C"},
+				expectedRemaining: []string{"AC", "4C", "5C"},
+			},
+			{
+				name:              "specific cards",
+				options:           services.DrawOptions{Cards: [][2]rune{{'4', 'C'}, {'A', 'C'}}, Position: services.PositionBottom},
+				expectedDrawn:     []string{"4C", "AC"},
+				expectedRemaining: []string{"2C", "3C", "5C"},
+			},
+		}
+
+		for _, test := range tests {
+			created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}, {'4', 'C'}, {'5', 'C'}}})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			response, err := service.DrawCards(created.DeckId, test.options)
+			if err != nil {
+				t.Fatalf("Unexpected error for %s: %v", test.name, err)
+			}
+
+			if !reflect.DeepEqual(codesOf(response.Cards), test.expectedDrawn) {
+				t.Errorf("Unexpected cards drawn from %s. Expected: %+v, Got: %+v", test.name, test.expectedDrawn, codesOf(response.Cards))
+			}
+
+			deck, err := service.OpenDeck(created.DeckId)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(codesOf(deck.Cards), test.expectedRemaining) {
+				t.Errorf("Unexpected cards remaining after drawing from %s. Expected: %+v, Got: %+v", test.name, test.expectedRemaining, codesOf(deck.Cards))
+			}
+
+			// Drawn cards can be returned like cards drawn from the top
+			returned, err := service.ReturnCards(created.DeckId, services.ReturnOptions{Position: services.PositionTop})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(codesOf(returned.Cards), test.expectedDrawn) {
+				t.Errorf("Unexpected cards returned after drawing from %s. Expected: %+v, Got: %+v", test.name, test.expectedDrawn, codesOf(returned.Cards))
+			}
+		}
+	})
+}
+
+func TestDrawCards_DrawsDistinctRandomCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		response, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 10, Position: services.PositionRandom})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		seen := make(map[string]bool)
+		for _, code := range append(codesOf(response.Cards), codesOf(deck.Cards)...) {
+			if seen[code] {
+				t.Errorf("Card %s is both drawn and remaining", code)
+			}
+			seen[code] = true
+		}
+
+		if len(response.Cards) != 10 || len(seen) != 52 {
+			t.Errorf("Unexpected cards. Drawn: %+v, Remaining: %+v", len(response.Cards), len(deck.Cards))
+		}
+	})
+}
+
+func TestDrawCards_ErrorIfCardIsGone(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 1})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.AddToPile(created.DeckId, "hand", 1, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		tests := map[[2]rune]string{
+			{'A', 'C'}: "card AC has already been drawn from deck id: " + created.DeckId,
+			{'2', 'C'}: "card 2C is in pile hand of deck id: " + created.DeckId,
+			{'K', 'S'}: "card KS is not part of deck id: " + created.DeckId,
+		}
+
+		for code, expectedError := range tests {
+			_, err := service.DrawCards(created.DeckId, services.DrawOptions{Cards: [][2]rune{{'3', 'C'}, code}})
+
+			if err == nil {
+				t.Errorf("Expected error was not returned: %s", expectedError)
+			} else if expectedError != err.Error() {
+				t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err.Error())
+			} else if !errors.Is(err, services.ErrCardNotInDeck) {
+				t.Errorf("Unexpected error kind. Expected: %+v, Got: %+v", services.ErrCardNotInDeck, err)
+			}
+		}
+
+		// Failed draws do not draw any card
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if deck.Remaining != 1 {
+			t.Errorf("Unexpected remaining count. Expected: %+v, Got: %+v", 1, deck.Remaining)
+		}
+	})
+}
+
+func TestDrawCards_ErrorIfIndexOutOfRange(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 2, Position: services.PositionIndex, Index: 2})
+		if !errors.Is(err, services.ErrInsufficientCards) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInsufficientCards, err)
+		}
+
+		_, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 1, Position: "middle"})
+		if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package services_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Time to live of decks in expiry tests
+const ttl = time.Hour
+
+// Clock that only moves when told to
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Creates a deck with a single card in the repository
+func createDeck(t *testing.T, store services.DecksStorer) *models.Deck {
+	deck := &models.Deck{
+		Cards:     cardsOf("AC"),
+		Remaining: 1,
+	}
+	if _, err := store.Create(deck); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return deck
+}
+
+func TestExpiry_SetsTimestamps(t *testing.T) {
+
+	clock := newFakeClock()
+	store := services.NewExpiringDecksInMemoryStore(ttl, clock)
+
+	created := createDeck(t, store)
+
+	if !created.CreatedAt.Equal(clock.Now()) || !created.LastAccessed.Equal(clock.Now()) {
+		t.Errorf("Unexpected timestamps. Expected: %+v, Got: %+v and %+v", clock.Now(), created.CreatedAt, created.LastAccessed)
+	}
+
+	clock.Advance(time.Minute)
+
+	deck, err := store.Get(created.DeckId)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !deck.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Unexpected creation time. Expected: %+v, Got: %+v", created.CreatedAt, deck.CreatedAt)
+	}
+
+	if !deck.LastAccessed.Equal(clock.Now()) {
+		t.Errorf("Unexpected access time. Expected: %+v, Got: %+v", clock.Now(), deck.LastAccessed)
+	}
+}
+
+func TestExpiry_EvictsOnlyExpiredDecks(t *testing.T) {
+
+	clock := newFakeClock()
+	store := services.NewExpiringDecksInMemoryStore(ttl, clock)
+
+	abandoned := createDeck(t, store)
+	clock.Advance(ttl / 2)
+	active := createDeck(t, store)
+	clock.Advance(ttl / 2)
+
+	// Accessing a deck keeps it alive
+	if _, err := store.Get(active.DeckId); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	clock.Advance(time.Second)
+
+	evicted := store.EvictExpired()
+	if evicted != 1 {
+		t.Errorf("Unexpected number of evicted decks. Expected: %+v, Got: %+v", 1, evicted)
+	}
+
+	if _, err := store.Get(abandoned.DeckId); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrNotFound, err)
+	}
+
+	if _, err := store.Get(active.DeckId); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestExpiry_ExpiredDeckNotFoundBeforeEviction(t *testing.T) {
+
+	clock := newFakeClock()
+	store := services.NewExpiringDecksInMemoryStore(ttl, clock)
+
+	created := createDeck(t, store)
+	clock.Advance(ttl + time.Second)
+
+	if _, err := store.Get(created.DeckId); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrNotFound, err)
+	}
+
+	_, err := store.Update(created.DeckId, func(deck *models.Deck) error {
+		return nil
+	})
+	if !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrNotFound, err)
+	}
+}
+
+func TestExpiry_DecksNeverExpireWithoutTTL(t *testing.T) {
+
+	clock := newFakeClock()
+	store := services.NewExpiringDecksInMemoryStore(0, clock)
+
+	created := createDeck(t, store)
+	clock.Advance(100 * 365 * 24 * time.Hour)
+
+	if evicted := store.EvictExpired(); evicted != 0 {
+		t.Errorf("Unexpected number of evicted decks. Expected: %+v, Got: %+v", 0, evicted)
+	}
+
+	if _, err := store.Get(created.DeckId); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestJanitor_EvictsInBackgroundUntilClosed(t *testing.T) {
+
+	clock := newFakeClock()
+	store := services.NewExpiringDecksInMemoryStore(ttl, clock)
+
+	createDeck(t, store)
+	clock.Advance(ttl + time.Second)
+
+	store.StartJanitor(time.Millisecond)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for store.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if store.Len() != 0 {
+		t.Errorf("Expired deck was not evicted by the janitor")
+	}
+
+	if err := store.Close(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Decks expiring after the janitor stopped are no longer evicted in the background
+	createDeck(t, store)
+	clock.Advance(ttl + time.Second)
+	time.Sleep(20 * time.Millisecond)
+
+	if store.Len() != 1 {
+		t.Errorf("Deck was evicted after the janitor was stopped")
+	}
+}
@@ -0,0 +1,106 @@
+package services_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// A standard deck with two jokers
+func createMockDecksConfigurationWithJokers() configs.DecksConfig {
+	decksConfig := createMockDecksConfiguration()
+	decksConfig.Extras = []configs.ExtraCardConfig{
+		{Code: "XR", Value: "JOKER", Suit: "RED"},
+		{Code: "XB", Value: "JOKER", Suit: "BLACK"},
+	}
+	return decksConfig
+}
+
+func TestCreateDeck_IncludesExtraCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfigurationWithJokers(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if created.Remaining != 54 {
+			t.Errorf("Unexpected remaining count. Expected: %+v, Got: %+v", 54, created.Remaining)
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Extra cards follow all other cards
+		expectedCards := []dto.CardDto{
+			{Suit: "SPADES", Value: "KING", Code: "KS"},
+			{Suit: "RED", Value: "JOKER", Code: "XR"},
+			{Suit: "BLACK", Value: "JOKER", Code: "XB"},
+		}
+
+		if !reflect.DeepEqual(deck.Cards[51:], expectedCards) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCards, deck.Cards[51:])
+		}
+	})
+}
+
+func TestCreateDeck_SelectsAndShufflesExtraCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfigurationWithJokers(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, Cards: [][2]rune{{'X', 'B'}, {'A', 'S'}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		response, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		drawn := make(map[string]dto.CardDto)
+		for _, card := range response.Cards {
+			drawn[card.Code] = card
+		}
+
+		expectedCards := map[string]dto.CardDto{
+			"XB": {Suit: "BLACK", Value: "JOKER", Code: "XB"},
+			"AS": {Suit: "SPADES", Value: "ACE", Code: "AS"},
+		}
+
+		if !reflect.DeepEqual(drawn, expectedCards) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCards, drawn)
+		}
+	})
+}
+
+func TestNewDecksService_PanicsOnBadExtraCards(t *testing.T) {
+
+	tests := map[string][]configs.ExtraCardConfig{
+		"code too long":        {{Code: "XXL", Value: "JOKER"}},
+		"duplicate extra code": {{Code: "XR", Value: "JOKER"}, {Code: "XR", Value: "JOKER"}},
+		"code of grid card":    {{Code: "AS", Value: "ACE OF SPADES"}},
+	}
+
+	for name, extras := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected panic did not happen")
+				}
+			}()
+
+			config := createMockDecksConfiguration()
+			config.Extras = extras
+			services.NewDecksService(config, services.NewDecksInMemoryStore())
+		})
+	}
+}
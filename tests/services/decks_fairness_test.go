@@ -0,0 +1,250 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/fairness"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestCreateDeck_CommitsToFairShuffle(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle:    true,
+			Fair:       true,
+			ClientSeed: "player",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if created.Fairness == nil || len(created.Fairness.Commitment) != 64 || created.Fairness.ClientSeed != "player" {
+			t.Fatalf("Unexpected fairness: %+v", created.Fairness)
+		}
+
+		// The server seed stays secret until the deck is exhausted
+		if created.Fairness.ServerSeed != "" || created.Fairness.Revealed {
+			t.Errorf("Server seed was revealed at creation: %+v", created.Fairness)
+		}
+
+		verification, err := service.VerifyDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if verification.Fairness.ServerSeed != "" || verification.ShuffledCards != nil || len(verification.InitialCards) != 52 {
+			t.Errorf("Unexpected verification before reveal: %+v", verification)
+		}
+	})
+}
+
+func TestDrawCards_RevealsServerSeedOfExhaustedFairDeck(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle:    true,
+			Fair:       true,
+			ClientSeed: "player",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		first, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 50})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		verification, err := service.VerifyDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if verification.Fairness.Revealed {
+			t.Errorf("Server seed was revealed before the deck was exhausted: %+v", verification.Fairness)
+		}
+
+		last, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		verification, err = service.VerifyDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !verification.Fairness.Revealed || !verification.Verified {
+			t.Fatalf("Unexpected verification: %+v", verification.Fairness)
+		}
+
+		// Players can check the cards they were dealt on their own
+		dealt := append(codesOf(first.Cards), codesOf(last.Cards)...)
+		err = fairness.Verify(
+			verification.Fairness.ServerSeed,
+			created.Fairness.Commitment,
+			"player",
+			codesOf(verification.InitialCards),
+			dealt,
+		)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestUndo_ErrorIfServerSeedRevealed(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle:    true,
+			Fair:       true,
+			ClientSeed: "player",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Before the server seed is revealed, draws can be undone
+		if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.Undo(created.DeckId, 1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 52}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.Undo(created.DeckId, 1)
+		if !errors.Is(err, services.ErrDeckClosed) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrDeckClosed, err)
+		}
+
+		// The exhausted deck is left as it was
+		_, err = service.OpenDeck(created.DeckId)
+		if !errors.Is(err, services.ErrEmptyDeck) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrEmptyDeck, err)
+		}
+	})
+}
+
+func TestFairDeck_ErrorIfOrderChangedBeforeReveal(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle:    true,
+			Fair:       true,
+			ClientSeed: "player",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.AddToPile(created.DeckId, "discard", 1, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		before, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		changes := map[string]func() error{
+			"shuffle": func() error {
+				_, err := service.ShuffleDeck(created.DeckId, services.ShuffleOptions{})
+				return err
+			},
+			"operation": func() error {
+				_, err := service.ApplyOperation(created.DeckId, services.OperationOptions{Operation: services.OperationRiffle})
+				return err
+			},
+			"return": func() error {
+				_, err := service.ReturnCards(created.DeckId, services.ReturnOptions{Position: services.PositionBottom})
+				return err
+			},
+			"return pile": func() error {
+				_, err := service.ReturnPile(created.DeckId, "discard", services.PositionRandom)
+				return err
+			},
+		}
+		for name, change := range changes {
+			if err := change(); !errors.Is(err, services.ErrDeckClosed) {
+				t.Errorf("Unexpected error for %s. Expected: %+v, Got: %+v", name, services.ErrDeckClosed, err)
+			}
+		}
+
+		after, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(codesOf(after.Cards), codesOf(before.Cards)) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", codesOf(before.Cards), codesOf(after.Cards))
+		}
+
+		// Once the server seed is revealed, the dealt order can be verified, and the deck can be shuffled again
+		if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 49}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.ReturnCards(created.DeckId, services.ReturnOptions{}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if _, err := service.ShuffleDeck(created.DeckId, services.ShuffleOptions{}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCreateDeck_ErrorIfFairOptionsInvalid(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		seed := int64(1)
+
+		tests := map[string]services.CreateDeckOptions{
+			"no client seed": {Shuffle: true, Fair: true},
+			"not shuffled":   {Shuffle: false, Fair: true, ClientSeed: "player"},
+			"seeded":         {Shuffle: true, Fair: true, ClientSeed: "player", Seed: &seed},
+		}
+
+		for name, options := range tests {
+			_, err := service.CreateDeck(options)
+			if !errors.Is(err, services.ErrInvalidParameter) {
+				t.Errorf("Unexpected error for %s. Expected: %+v, Got: %+v", name, services.ErrInvalidParameter, err)
+			}
+		}
+	})
+}
+
+func TestVerifyDeck_ErrorIfDeckNotFair(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.VerifyDeck(created.DeckId)
+		if !errors.Is(err, services.ErrNotProvablyFair) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrNotProvablyFair, err)
+		}
+	})
+}
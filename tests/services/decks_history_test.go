@@ -0,0 +1,246 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Returns the types of the events of the history of a deck
+func historyTypes(t *testing.T, service services.DecksServicer, deckId string) []string {
+	history, err := service.History(deckId)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	types := make([]string, len(history.Events))
+	for i, event := range history.Events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestHistory_RecordsEveryMutation(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		dealer := service.WithActor("dealer")
+		seed := int64(42)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := dealer.DrawCards(created.DeckId, services.DrawOptions{Count: 2}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := dealer.ReturnCards(created.DeckId, services.ReturnOptions{Cards: [][2]rune{{'A', 'C'}}, Position: services.PositionBottom}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := dealer.ShuffleDeck(created.DeckId, services.ShuffleOptions{Seed: &seed}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := dealer.Deal(created.DeckId, []string{"alice", "bob"}, 2, 1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// Reads are not recorded
+		if _, err := dealer.OpenDeck(created.DeckId); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		history, err := service.History(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := []struct {
+			eventType string
+			actor     string
+			details   map[string]string
+			remaining int
+		}{
+			{services.EventCreate, "", map[string]string{"type": "standard", "shuffle": "false"}, 52},
+			{services.EventDraw, "dealer", map[string]string{"count": "2", "position": "top"}, 50},
+			{services.EventReturn, "dealer", map[string]string{"cards": "AC", "position": "bottom"}, 51},
+			{services.EventShuffle, "dealer", map[string]string{"seed": "42"}, 51},
+			{services.EventDeal, "dealer", map[string]string{"players": "alice,bob", "count": "2", "burn": "1"}, 46},
+		}
+
+		if history.DeckId != created.DeckId || len(history.Events) != len(expected) {
+			t.Fatalf("Unexpected history. Got: %+v", history)
+		}
+
+		for i, event := range history.Events {
+			if event.Type != expected[i].eventType || event.Actor != expected[i].actor || !reflect.DeepEqual(event.Details, expected[i].details) ||
+				event.Remaining != expected[i].remaining || event.Undone || event.Time.IsZero() {
+				t.Errorf("Unexpected event. Expected: %+v, Got: %+v", expected[i], event)
+			}
+			if i > 0 && event.Time.Before(history.Events[i-1].Time) {
+				t.Errorf("Events are not in order: %+v", history.Events)
+			}
+		}
+	})
+}
+
+func TestUndo_RevertsLastOperations(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}, {'4', 'C'}, {'5', 'C'}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 1}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedDeck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, err := service.ShuffleDeck(created.DeckId, services.ShuffleOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.AddToPile(created.DeckId, "discard", 2, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		response, err := service.Undo(created.DeckId, 2)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if response.Undone != 2 || response.Remaining != 4 || response.Shuffled {
+			t.Errorf("Unexpected response. Got: %+v", response)
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(deck, expectedDeck) {
+			t.Errorf("Unexpected deck. Expected: %+v, Got: %+v", expectedDeck, deck)
+		}
+
+		// Operations after an undo apply to the rebuilt deck, and can be undone in turn
+		if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 1}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.Undo(created.DeckId, 2); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedCodes := []string{"AC", "2C", "3C", "4C", "5C"}
+		codes := remainingCodes(t, service, created.DeckId)
+
+		if !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+
+		history, err := service.History(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedUndone := []bool{false, true, true, true, false, true, false}
+		undone := make([]bool, len(history.Events))
+		for i, event := range history.Events {
+			undone[i] = event.Undone
+		}
+
+		if !reflect.DeepEqual(undone, expectedUndone) {
+			t.Errorf("Unexpected undone events. Expected: %+v, Got: %+v", expectedUndone, undone)
+		}
+	})
+}
+
+func TestUndo_ErrorIfNotEnoughOperations(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithTwoDrawn(t, service)
+
+		for _, count := range []int{0, 2} {
+			_, err := service.Undo(deckId, count)
+			if !errors.Is(err, services.ErrInvalidParameter) {
+				t.Errorf("Unexpected error undoing %d operation(s). Expected: %+v, Got: %+v", count, services.ErrInvalidParameter, err)
+			}
+		}
+
+		// Neither the creation of the deck nor an undo can be undone
+		if _, err := service.Undo(deckId, 1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		_, err := service.Undo(deckId, 1)
+		if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+
+		expectedTypes := []string{services.EventCreate, services.EventDraw, services.EventUndo}
+		types := historyTypes(t, service, deckId)
+
+		if !reflect.DeepEqual(types, expectedTypes) {
+			t.Errorf("Unexpected history. Expected: %+v, Got: %+v", expectedTypes, types)
+		}
+	})
+}
+
+func TestHistory_CompactsOldestEvents(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Draws and returns a card in turn, so that the deck has 51 cards left after each draw
+		for i := 0; i < services.MaxHistoryEvents; i++ {
+			if i%2 == 0 {
+				_, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 1})
+			} else {
+				_, err = service.ReturnCards(created.DeckId, services.ReturnOptions{})
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		history, err := service.History(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(history.Events) != services.MaxHistoryEvents/2 {
+			t.Fatalf("Unexpected number of events. Expected: %+v, Got: %+v", services.MaxHistoryEvents/2, len(history.Events))
+		}
+
+		// The creation and the oldest draws and returns are merged into the first event
+		first := history.Events[0]
+		expectedCompacted := strconv.Itoa(services.MaxHistoryEvents/2 + 2)
+
+		if first.Type != services.EventCreate || first.Details["compacted"] != expectedCompacted {
+			t.Errorf("Unexpected first event. Expected %s compacting %s events, Got: %+v", services.EventCreate, expectedCompacted, first)
+		}
+
+		// The kept events can be undone, back to the state of the merged events
+		undone, err := service.Undo(created.DeckId, len(history.Events)-1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if undone.Remaining != 51 {
+			t.Errorf("Unexpected remaining count. Expected: %+v, Got: %+v", 51, undone.Remaining)
+		}
+
+		_, err = service.Undo(created.DeckId, 1)
+		if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package services_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Visible state of a deck, to compare a deck before and after it is rebuilt from the files of a journal
+type journaledDeck struct {
+	deck    *dto.OpenDeckResponse
+	history *dto.HistoryResponse
+}
+
+// Creates a deck and changes it in every possible way, with changes journaled as updates and as whole decks
+func createJournaledDeck(t *testing.T, service services.DecksServicer) string {
+	created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, DeckCount: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.Deal(created.DeckId, []string{"alice", "bob"}, 2, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.ApplyOperation(created.DeckId, services.OperationOptions{Operation: services.OperationRiffle}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.ReturnPile(created.DeckId, "bob", services.PositionBottom); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.Undo(created.DeckId, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return created.DeckId
+}
+
+// Returns the visible state of a deck
+func journaledDeckOf(t *testing.T, service services.DecksServicer, deckId string) journaledDeck {
+	deck, err := service.OpenDeck(deckId)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	history, err := service.History(deckId)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return journaledDeck{deck: deck, history: history}
+}
+
+// Copies the files of a journal repository to a new directory, as they would be found after a crash
+func copyJournal(t *testing.T, dir string) string {
+	copied := t.TempDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(copied, entry.Name()), data, 0o644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return copied
+}
+
+func TestJournalStore_DecksSurviveReopening(t *testing.T) {
+
+	dir := t.TempDir()
+
+	store := newJournalStore(t, dir)
+	service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+	deckId := createJournaledDeck(t, service)
+	expected := journaledDeckOf(t, service, deckId)
+
+	// Without closing the repository, the decks are rebuilt from the journal alone
+	crashed := services.NewDecksService(createMockDecksConfiguration(), newJournalStore(t, copyJournal(t, dir)))
+
+	if got := journaledDeckOf(t, crashed, deckId); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected deck after crash. Expected: %+v, Got: %+v", expected, got)
+	}
+
+	// Closing the repository compacts the journal into a snapshot, and closing it again has no effect
+	if err := store.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Unexpected error closing again: %v", err)
+	}
+
+	journal, err := os.ReadFile(filepath.Join(dir, "journal.log"))
+	if err != nil || len(journal) != 0 {
+		t.Errorf("Journal was not emptied by compaction: %q, %v", journal, err)
+	}
+
+	reopened := services.NewDecksService(createMockDecksConfiguration(), newJournalStore(t, dir))
+
+	if got := journaledDeckOf(t, reopened, deckId); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected deck after reopening. Expected: %+v, Got: %+v", expected, got)
+	}
+}
+
+func TestJournalStore_JournalsChangesOfUpdates(t *testing.T) {
+
+	dir := t.TempDir()
+
+	service := services.NewDecksService(createMockDecksConfiguration(), newJournalStore(t, dir))
+	deckId := createDeckWithTwoDrawn(t, service)
+
+	journal, err := os.ReadFile(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(journal, []byte("\n")), []byte("\n"))
+	if len(lines) != 2 || !bytes.Contains(lines[0], []byte(`"op":"put"`)) || !bytes.Contains(lines[1], []byte(`"op":"update"`)) {
+		t.Errorf("Unexpected journal of deck %s: %s", deckId, journal)
+	}
+}
+
+func TestJournalStore_TruncatesTornEntry(t *testing.T) {
+
+	dir := t.TempDir()
+
+	service := services.NewDecksService(createMockDecksConfiguration(), newJournalStore(t, dir))
+	deckId := createJournaledDeck(t, service)
+	expected := journaledDeckOf(t, service, deckId)
+
+	// A crash while appending an entry leaves an incomplete last line
+	crashed := copyJournal(t, dir)
+	journal, err := os.OpenFile(filepath.Join(crashed, "journal.log"), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	journal.WriteString(`1234abcd {"seq":99,"op":"upd`)
+	journal.Close()
+
+	reopened := services.NewDecksService(createMockDecksConfiguration(), newJournalStore(t, crashed))
+
+	if got := journaledDeckOf(t, reopened, deckId); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected deck after crash. Expected: %+v, Got: %+v", expected, got)
+	}
+
+	// Entries appended after the truncated one are replayed
+	if _, err := reopened.DrawCards(deckId, services.DrawOptions{Count: 1}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = journaledDeckOf(t, reopened, deckId)
+
+	again := services.NewDecksService(createMockDecksConfiguration(), newJournalStore(t, copyJournal(t, crashed)))
+
+	if got := journaledDeckOf(t, again, deckId); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected deck after second crash. Expected: %+v, Got: %+v", expected, got)
+	}
+}
+
+func TestJournalStore_ErrorIfEntryBeforeLastIsCorrupt(t *testing.T) {
+
+	dir := t.TempDir()
+
+	service := services.NewDecksService(createMockDecksConfiguration(), newJournalStore(t, dir))
+	createDeckWithTwoDrawn(t, service)
+
+	// An invalid line followed by other entries is not a torn entry, and cutting it would lose the later ones
+	corrupted := copyJournal(t, dir)
+	path := filepath.Join(corrupted, "journal.log")
+	journal, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	journal = bytes.Replace(journal, []byte(`"op":"put"`), []byte(`"op":"p?t"`), 1)
+	if err := os.WriteFile(path, journal, 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := services.NewDecksJournalStore(corrupted); err == nil {
+		t.Errorf("Unexpected success opening a corrupt journal")
+	}
+
+	// The journal is left as it was
+	if left, err := os.ReadFile(path); err != nil || !bytes.Equal(left, journal) {
+		t.Errorf("Corrupt journal was changed: %q, %v", left, err)
+	}
+}
+
+func TestJournalStore_EntriesInSnapshotAreNotReplayed(t *testing.T) {
+
+	dir := t.TempDir()
+
+	store := newJournalStore(t, dir)
+	service := services.NewDecksService(createMockDecksConfiguration(), store)
+	deckId := createJournaledDeck(t, service)
+	expected := journaledDeckOf(t, service, deckId)
+
+	journal, err := os.ReadFile(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := store.Compact(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A crash after the snapshot is written, but before the journal is emptied
+	crashed := copyJournal(t, dir)
+	if err := os.WriteFile(filepath.Join(crashed, "journal.log"), journal, 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reopened := services.NewDecksService(createMockDecksConfiguration(), newJournalStore(t, crashed))
+
+	if got := journaledDeckOf(t, reopened, deckId); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected deck after crash. Expected: %+v, Got: %+v", expected, got)
+	}
+}
@@ -0,0 +1,189 @@
+package services_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestDeleteDeck_RemovesDeck(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if err := service.DeleteDeck(created.DeckId); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, err := service.OpenDeck(created.DeckId); !errors.Is(err, services.ErrNotFound) {
+			t.Errorf("Unexpected error opening deleted deck. Expected: %+v, Got: %+v", services.ErrNotFound, err)
+		}
+		if err := service.DeleteDeck(created.DeckId); !errors.Is(err, services.ErrNotFound) {
+			t.Errorf("Unexpected error deleting deleted deck. Expected: %+v, Got: %+v", services.ErrNotFound, err)
+		}
+		if err := service.DeleteDeck("not-a-uuid"); !errors.Is(err, services.ErrInvalidId) {
+			t.Errorf("Unexpected error deleting invalid id. Expected: %+v, Got: %+v", services.ErrInvalidId, err)
+		}
+	})
+}
+
+func TestCloseDeck_RejectsChangesAndKeepsDeckReadable(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		deckId := createDeckWithTwoDrawn(t, service)
+		if _, err := service.AddToPile(deckId, "discard", 1, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		before, err := service.OpenDeck(deckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		closed, err := service.CloseDeck(deckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedClosed := &dto.CloseDeckResponse{DeckId: deckId, Remaining: before.Remaining, Closed: true}
+
+		if !reflect.DeepEqual(closed, expectedClosed) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedClosed, closed)
+		}
+
+		changes := map[string]func() error{
+			"draw": func() error {
+				_, err := service.DrawCards(deckId, services.DrawOptions{Count: 1})
+				return err
+			},
+			"return": func() error {
+				_, err := service.ReturnCards(deckId, services.ReturnOptions{})
+				return err
+			},
+			"shuffle": func() error {
+				_, err := service.ShuffleDeck(deckId, services.ShuffleOptions{})
+				return err
+			},
+			"operation": func() error {
+				_, err := service.ApplyOperation(deckId, services.OperationOptions{Operation: services.OperationRiffle})
+				return err
+			},
+			"deal": func() error {
+				_, err := service.Deal(deckId, []string{"alice"}, 1, 0)
+				return err
+			},
+			"pile": func() error {
+				_, err := service.DrawFromPile(deckId, "discard", 1)
+				return err
+			},
+			"undo": func() error {
+				_, err := service.Undo(deckId, 1)
+				return err
+			},
+			"close": func() error {
+				_, err := service.CloseDeck(deckId)
+				return err
+			},
+		}
+		for name, change := range changes {
+			if err := change(); !errors.Is(err, services.ErrDeckClosed) {
+				t.Errorf("Unexpected error for %s of closed deck. Expected: %+v, Got: %+v", name, services.ErrDeckClosed, err)
+			}
+		}
+
+		after, err := service.OpenDeck(deckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		before.Closed = true
+		if !reflect.DeepEqual(after, before) {
+			t.Errorf("Unexpected closed deck. Expected: %+v, Got: %+v", before, after)
+		}
+
+		if types := historyTypes(t, service, deckId); types[len(types)-1] != services.EventClose {
+			t.Errorf("Unexpected history of closed deck: %+v", types)
+		}
+	})
+}
+
+func TestCloseDeck_RevealsServerSeed(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, Fair: true, ClientSeed: "player"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		closed, err := service.CloseDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if closed.Fairness == nil || !closed.Fairness.Revealed || closed.Fairness.ServerSeed == "" {
+			t.Errorf("Unexpected fairness of closed deck: %+v", closed.Fairness)
+		}
+
+		verification, err := service.VerifyDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !verification.Verified || len(verification.ShuffledCards) != 52 {
+			t.Errorf("Unexpected verification of closed deck: %+v", verification)
+		}
+	})
+}
+
+func TestCloseDeck_PersistsClosedDecks(t *testing.T) {
+
+	// Each repository is reopened from its files while the first one is still open, so the journal
+	// is replayed from the changes of its entries, and not from a snapshot
+	stores := map[string]struct {
+		open   func(t *testing.T, dir string) services.DecksStorer
+		reopen func(t *testing.T, dir string) services.DecksStorer
+	}{
+		"sqlite": {
+			open: func(t *testing.T, dir string) services.DecksStorer {
+				return newSqliteStore(t, filepath.Join(dir, "decks.db"))
+			},
+			reopen: func(t *testing.T, dir string) services.DecksStorer {
+				return newSqliteStore(t, filepath.Join(dir, "decks.db"))
+			},
+		},
+		"journal": {
+			open: func(t *testing.T, dir string) services.DecksStorer {
+				return newJournalStore(t, dir)
+			},
+			reopen: func(t *testing.T, dir string) services.DecksStorer {
+				return newJournalStore(t, copyJournal(t, dir))
+			},
+		},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			service := services.NewDecksService(createMockDecksConfiguration(), store.open(t, dir))
+
+			deckId := createDeckWithTwoDrawn(t, service)
+			if _, err := service.CloseDeck(deckId); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			reopened := services.NewDecksService(createMockDecksConfiguration(), store.reopen(t, dir))
+			if _, err := reopened.DrawCards(deckId, services.DrawOptions{Count: 1}); !errors.Is(err, services.ErrDeckClosed) {
+				t.Errorf("Unexpected error drawing from reopened closed deck. Expected: %+v, Got: %+v", services.ErrDeckClosed, err)
+			}
+		})
+	}
+}
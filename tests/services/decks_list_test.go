@@ -0,0 +1,188 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Lists all pages of the decks matching a filter, and returns the ids of the decks
+func listAllDecks(t *testing.T, service services.DecksServicer, filter services.DecksFilter, limit int) []string {
+	var ids []string
+	cursor := ""
+	for {
+		page, err := service.ListDecks(filter, cursor, limit)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(page.Decks) > limit {
+			t.Fatalf("Unexpected number of decks in page. Expected at most: %+v, Got: %+v", limit, len(page.Decks))
+		}
+		for _, deck := range page.Decks {
+			ids = append(ids, deck.DeckId)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func TestListDecks_PaginatesInIdOrder(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		var expected []string
+		for i := 0; i < 5; i++ {
+			created, err := service.CreateDeck(services.CreateDeckOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			expected = append(expected, created.DeckId)
+		}
+		sort.Strings(expected)
+
+		for _, limit := range []int{1, 2, 5, 10} {
+			if ids := listAllDecks(t, service, services.DecksFilter{}, limit); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("Unexpected decks with limit %d. Expected: %+v, Got: %+v", limit, expected, ids)
+			}
+		}
+
+		// A full last page has no next page
+		page, err := service.ListDecks(services.DecksFilter{}, expected[2], 2)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(page.Decks) != 2 || page.NextCursor != "" {
+			t.Errorf("Unexpected last page. Expected: 2 decks without cursor, Got: %+v", page)
+		}
+	})
+}
+
+func TestListDecks_ReturnsSummaries(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Cards:  [][2]rune{{'A', 'S'}, {'K', 'H'}, {'2', 'C'}},
+			Labels: map[string]string{"table": "3"},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.AddToPile(created.DeckId, "discard", 1, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		page, err := service.ListDecks(services.DecksFilter{}, "", 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(page.Decks) != 1 {
+			t.Fatalf("Unexpected number of decks. Expected: %+v, Got: %+v", 1, len(page.Decks))
+		}
+
+		summary := page.Decks[0]
+		if summary.DeckId != created.DeckId || summary.Remaining != 2 || summary.Type != "standard" ||
+			!reflect.DeepEqual(summary.Labels, map[string]string{"table": "3"}) ||
+			!reflect.DeepEqual(summary.Piles, map[string]int{"discard": 1}) || summary.CreatedAt.IsZero() {
+			t.Errorf("Unexpected summary. Got: %+v", summary)
+		}
+	})
+}
+
+func TestListDecks_FiltersDecks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfigurationWithTypes(), store)
+
+		create := func(options services.CreateDeckOptions, draw int) string {
+			created, err := service.CreateDeck(options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if draw > 0 {
+				if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: draw}); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			return created.DeckId
+		}
+
+		standard := create(services.CreateDeckOptions{Labels: map[string]string{"game": "poker", "table": "1"}}, 0)
+		shuffled := create(services.CreateDeckOptions{Shuffle: true, Labels: map[string]string{"game": "poker", "table": "2"}}, 50)
+		// Timestamps of decks created in the same instant cannot be told apart
+		time.Sleep(time.Millisecond)
+		between := time.Now()
+		time.Sleep(time.Millisecond)
+		piquet := create(services.CreateDeckOptions{Type: "piquet", Labels: map[string]string{"game": "belote"}}, 2)
+
+		yes, no := true, false
+		two, thirty, fifty := 2, 30, 50
+
+		tests := []struct {
+			name     string
+			filter   services.DecksFilter
+			expected []string
+		}{
+			{"all", services.DecksFilter{}, []string{standard, shuffled, piquet}},
+			{"created after", services.DecksFilter{CreatedAfter: between}, []string{piquet}},
+			{"shuffled", services.DecksFilter{Shuffled: &yes}, []string{shuffled}},
+			{"not shuffled", services.DecksFilter{Shuffled: &no}, []string{standard, piquet}},
+			{"minimum remaining", services.DecksFilter{MinRemaining: &thirty}, []string{standard, piquet}},
+			{"maximum remaining", services.DecksFilter{MaxRemaining: &thirty}, []string{shuffled, piquet}},
+			{"remaining range", services.DecksFilter{MinRemaining: &two, MaxRemaining: &fifty}, []string{shuffled, piquet}},
+			{"type", services.DecksFilter{Type: "piquet"}, []string{piquet}},
+			{"label", services.DecksFilter{Labels: map[string]string{"game": "poker"}}, []string{standard, shuffled}},
+			{"labels", services.DecksFilter{Labels: map[string]string{"game": "poker", "table": "2"}}, []string{shuffled}},
+			{"missing label", services.DecksFilter{Labels: map[string]string{"seat": "1"}}, nil},
+			{"all criteria", services.DecksFilter{Shuffled: &no, MaxRemaining: &fifty, Labels: map[string]string{"game": "belote"}}, []string{piquet}},
+		}
+
+		for _, test := range tests {
+			expected := append([]string(nil), test.expected...)
+			sort.Strings(expected)
+			if ids := listAllDecks(t, service, test.filter, 1); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("Unexpected decks listed by %s. Expected: %+v, Got: %+v", test.name, expected, ids)
+			}
+		}
+	})
+}
+
+func TestListDecks_ErrorIfInvalid(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		one, two := 1, 2
+		listings := []struct {
+			filter services.DecksFilter
+			cursor string
+			limit  int
+		}{
+			{services.DecksFilter{}, "", services.MaxListLimit + 1},
+			{services.DecksFilter{}, "", -1},
+			{services.DecksFilter{}, "not-a-cursor", 0},
+			{services.DecksFilter{MinRemaining: &two, MaxRemaining: &one}, "", 0},
+		}
+		for _, listing := range listings {
+			_, err := service.ListDecks(listing.filter, listing.cursor, listing.limit)
+			if !errors.Is(err, services.ErrInvalidParameter) {
+				t.Errorf("Unexpected error for %+v. Expected: %v, Got: %v", listing, services.ErrInvalidParameter, err)
+			}
+		}
+
+		for _, labels := range []map[string]string{{"": "x"}, {"a b": "x"}, {"key": "a,b"}} {
+			_, err := service.CreateDeck(services.CreateDeckOptions{Labels: labels})
+			if !errors.Is(err, services.ErrInvalidParameter) {
+				t.Errorf("Unexpected error for labels %+v. Expected: %v, Got: %v", labels, services.ErrInvalidParameter, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Creates an unshuffled deck of the given cards, with the first card drawn
+func createDeckWithOneDrawn(t *testing.T, service services.DecksServicer, cards ...string) string {
+	codes := make([][2]rune, len(cards))
+	for i, card := range cards {
+		runes := []rune(card)
+		codes[i] = [2]rune{runes[0], runes[1]}
+	}
+	created, err := service.CreateDeck(services.CreateDeckOptions{Cards: codes})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 1}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return created.DeckId
+}
+
+func TestApplyOperation_RearrangesRemainingCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		position := 2
+		tests := []struct {
+			options       services.OperationOptions
+			expectedCodes []string
+		}{
+			{services.OperationOptions{Operation: services.OperationCut, Position: &position}, []string{"4C", "5C", "6C", "2C", "3C"}},
+			{services.OperationOptions{Operation: services.OperationCut, Position: &position, Times: 2}, []string{"6C", "2C", "3C", "4C", "5C"}},
+			{services.OperationOptions{Operation: services.OperationFaroOut}, []string{"2C", "5C", "3C", "6C", "4C"}},
+			{services.OperationOptions{Operation: services.OperationFaroIn}, []string{"4C", "2C", "5C", "3C", "6C"}},
+			{services.OperationOptions{Operation: services.OperationFaroOut, Times: 4}, []string{"2C", "3C", "4C", "5C", "6C"}},
+		}
+
+		for _, test := range tests {
+			// The drawn ace is never moved by operations
+			deckId := createDeckWithOneDrawn(t, service, "AC", "2C", "3C", "4C", "5C", "6C")
+
+			response, err := service.ApplyOperation(deckId, test.options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if response.Remaining != 5 || response.Shuffled {
+				t.Errorf("Unexpected response. Got: %+v", response)
+			}
+
+			codes := remainingCodes(t, service, deckId)
+
+			if !reflect.DeepEqual(codes, test.expectedCodes) {
+				t.Errorf("Unexpected cards after %+v. Expected: %+v, Got: %+v", test.options, test.expectedCodes, codes)
+			}
+		}
+	})
+}
+
+func TestApplyOperation_RandomOperationsKeepCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		// Random cuts can add up to a whole turn of the deck, which a seeded source rules out
+		service := services.NewDecksServiceWithRandom(createMockDecksConfiguration(), store, services.NewSeededRandom(1))
+
+		for _, operation := range []string{services.OperationCut, services.OperationRiffle, services.OperationOverhand} {
+			created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, Seed: new(int64)})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			expectedCodes := remainingCodes(t, service, created.DeckId)
+
+			response, err := service.ApplyOperation(created.DeckId, services.OperationOptions{Operation: operation, Times: 7})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			deck, err := service.OpenDeck(created.DeckId)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !response.Shuffled || response.Remaining != 52 || deck.Seed != nil {
+				t.Errorf("Unexpected response after %s. Got: %+v and seed %+v", operation, response, deck.Seed)
+			}
+
+			codes := codesOf(deck.Cards)
+			if reflect.DeepEqual(codes, expectedCodes) {
+				t.Errorf("Cards were not rearranged by %s: %+v", operation, codes)
+			}
+
+			sort.Strings(codes)
+			sort.Strings(expectedCodes)
+			if !reflect.DeepEqual(codes, expectedCodes) {
+				t.Errorf("Unexpected cards after %s. Expected: %+v, Got: %+v", operation, expectedCodes, codes)
+			}
+		}
+	})
+}
+
+func TestApplyOperation_RecordsHistory(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithOneDrawn(t, service, "AC", "2C", "3C")
+
+		position := 1
+		if _, err := service.ApplyOperation(deckId, services.OperationOptions{Operation: services.OperationCut, Position: &position}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := service.ApplyOperation(deckId, services.OperationOptions{Operation: services.OperationRiffle, Times: 3}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		deck, err := store.Get(uuid.MustParse(deckId))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// The creation of the deck and the draw come first
+		if len(deck.History) != 4 {
+			t.Fatalf("Unexpected history. Got: %+v", deck.History)
+		}
+
+		expectedDetails := []map[string]string{
+			{"times": "1", "position": "1"},
+			{"times": "3"},
+		}
+
+		for i, operation := range []string{services.OperationCut, services.OperationRiffle} {
+			event := deck.History[2+i]
+			if event.Type != operation || !reflect.DeepEqual(event.Details, expectedDetails[i]) || event.Time.IsZero() {
+				t.Errorf("Unexpected event. Expected: %s %+v, Got: %+v", operation, expectedDetails[i], event)
+			}
+		}
+	})
+}
+
+func TestApplyOperation_ErrorIfInvalidOptions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithOneDrawn(t, service, "AC", "2C", "3C")
+
+		outside := 2
+		inside := 1
+		tests := []services.OperationOptions{
+			{Operation: "shuffle"},
+			{Operation: services.OperationCut, Position: &outside},
+			{Operation: services.OperationRiffle, Position: &inside},
+			{Operation: services.OperationRiffle, Times: services.MaxOperationTimes + 1},
+		}
+
+		for _, options := range tests {
+			_, err := service.ApplyOperation(deckId, options)
+			if !errors.Is(err, services.ErrInvalidParameter) {
+				t.Errorf("Unexpected error for %+v. Expected: %+v, Got: %+v", options, services.ErrInvalidParameter, err)
+			}
+		}
+
+		// Failed operations are not recorded, only the creation of the deck and the draw are
+		deck, err := store.Get(uuid.MustParse(deckId))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(deck.History) != 2 {
+			t.Errorf("Unexpected history. Got: %+v", deck.History)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestPeekCards_DoesNotDrawCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}, {'4', 'C'}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		tests := map[string][]string{
+			services.PositionTop:    {"AC", "2C"},
+			services.PositionBottom: {"4C", "3C"},
+		}
+
+		for position, expectedCodes := range tests {
+			response, err := service.PeekCards(created.DeckId, 2, position)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if response.Remaining != 4 || !reflect.DeepEqual(codesOf(response.Cards), expectedCodes) {
+				t.Errorf("Unexpected response peeking at %s. Expected: %+v, Got: %+v", position, expectedCodes, response)
+			}
+		}
+
+		// The peeked cards are still the next to be drawn
+		drawn, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedCodes := []string{"AC", "2C"}
+
+		if !reflect.DeepEqual(codesOf(drawn.Cards), expectedCodes) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCodes, codesOf(drawn.Cards))
+		}
+	})
+}
+
+func TestPeekCards_ErrorIfMoreCardsThanRemaining(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.PeekCards(created.DeckId, 2, "")
+		if !errors.Is(err, services.ErrInsufficientCards) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInsufficientCards, err)
+		}
+
+		_, err = service.PeekCards(created.DeckId, 1, services.PositionRandom)
+		if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+	})
+}
+
+func TestOpenDeck_ErrorIfDeckHidden(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Cards:  [][2]rune{{'A', 'C'}, {'2', 'C'}},
+			Hidden: true,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !created.Hidden {
+			t.Errorf("Unexpected response. Deck is not hidden: %+v", created)
+		}
+
+		_, err = service.OpenDeck(created.DeckId)
+
+		expectedError := "the cards of deck id " + created.DeckId + " are hidden, they can only be peeked at"
+
+		if err == nil {
+			t.Errorf("Expected error was not returned: %s", expectedError)
+		} else if expectedError != err.Error() {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err.Error())
+		} else if !errors.Is(err, services.ErrDeckHidden) {
+			t.Errorf("Unexpected error kind. Expected: %+v, Got: %+v", services.ErrDeckHidden, err)
+		}
+
+		response, err := service.PeekCards(created.DeckId, 1, services.PositionTop)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedCards := []dto.CardDto{{Suit: "CLUBS", Value: "ACE", Code: "AC"}}
+
+		if !reflect.DeepEqual(response.Cards, expectedCards) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCards, response.Cards)
+		}
+	})
+}
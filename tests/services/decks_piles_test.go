@@ -0,0 +1,262 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Creates an unshuffled deck of four clubs, and moves the two top cards (AC and 2C) to a pile
+func createDeckWithPile(t *testing.T, service services.DecksServicer, pile string) string {
+	created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}, {'4', 'C'}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.AddToPile(created.DeckId, pile, 2, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return created.DeckId
+}
+
+// Returns the codes of the cards in a pile, from bottom to top
+func pileCodes(t *testing.T, service services.DecksServicer, deckId string, pile string) []string {
+	response, err := service.ListPile(deckId, pile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return codesOf(response.Cards)
+}
+
+func TestAddToPile_DrawsFromDeck(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}, {'4', 'C'}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedResponse := &dto.PileResponse{
+			DeckId:    created.DeckId,
+			Remaining: 2,
+			Pile:      "hand",
+			Cards: []dto.CardDto{
+				{
+					Suit:  "CLUBS",
+					Value: "ACE",
+					Code:  "AC",
+				},
+				{
+					Suit:  "CLUBS",
+					Value: "2",
+					Code:  "2C",
+				},
+			},
+		}
+
+		response, err := service.AddToPile(created.DeckId, "hand", 2, nil)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(response, expectedResponse) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedResponse, response)
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedPiles := map[string]int{"hand": 2}
+		if !reflect.DeepEqual(deck.Piles, expectedPiles) {
+			t.Errorf("Unexpected piles. Expected: %+v, Got: %+v", expectedPiles, deck.Piles)
+		}
+
+		expectedCodes := []string{"3C", "4C"}
+		if codes := codesOf(deck.Cards); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+	})
+}
+
+func TestAddToPile_MovesDrawnCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithTwoDrawn(t, service)
+
+		_, err := service.AddToPile(deckId, "discard", 0, [][2]rune{{'2', 'C'}})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedCodes := []string{"2C"}
+		if codes := pileCodes(t, service, deckId, "discard"); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected pile cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+
+		// Cards in a pile are no longer drawn
+		returned, err := service.ReturnCards(deckId, services.ReturnOptions{Position: services.PositionTop})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedCodes = []string{"AC"}
+		if codes := codesOf(returned.Cards); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected returned cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+
+		// Cards that are not drawn cannot be added to a pile
+		_, err = service.AddToPile(deckId, "discard", 0, [][2]rune{{'3', 'C'}})
+		if !errors.Is(err, services.ErrCardNotDrawn) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrCardNotDrawn, err)
+		}
+	})
+}
+
+func TestAddToPile_ErrorIfNotEnoughCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithPile(t, service, "hand")
+
+		_, err := service.AddToPile(deckId, "hand", 3, nil)
+		if !errors.Is(err, services.ErrInsufficientCards) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInsufficientCards, err)
+		}
+	})
+}
+
+func TestPiles_ErrorIfNegativeCount(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithPile(t, service, "hand")
+
+		if _, err := service.AddToPile(deckId, "hand", -1, nil); !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error adding to pile. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+		if _, err := service.DrawFromPile(deckId, "hand", -1); !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error drawing from pile. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+
+		// Neither the deck nor the pile changed
+		if codes := pileCodes(t, service, deckId, "hand"); !reflect.DeepEqual(codes, []string{"AC", "2C"}) {
+			t.Errorf("Unexpected pile cards. Expected: %+v, Got: %+v", []string{"AC", "2C"}, codes)
+		}
+		if codes := remainingCodes(t, service, deckId); !reflect.DeepEqual(codes, []string{"3C", "4C"}) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", []string{"3C", "4C"}, codes)
+		}
+	})
+}
+
+func TestDrawFromPile_DrawsFromTop(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithPile(t, service, "hand")
+
+		response, err := service.DrawFromPile(deckId, "hand", 1)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedCodes := []string{"2C"}
+		if codes := codesOf(response.Cards); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected drawn cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+
+		expectedCodes = []string{"AC"}
+		if codes := pileCodes(t, service, deckId, "hand"); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected pile cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+
+		// Cards drawn from a pile can be returned to the deck
+		_, err = service.ReturnCards(deckId, services.ReturnOptions{Cards: [][2]rune{{'2', 'C'}}, Position: services.PositionBottom})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		_, err = service.DrawFromPile(deckId, "hand", 2)
+		if !errors.Is(err, services.ErrInsufficientCards) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInsufficientCards, err)
+		}
+	})
+}
+
+func TestShufflePile_KeepsCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithPile(t, service, "hand")
+
+		response, err := service.ShufflePile(deckId, "hand", services.ShuffleOptions{})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		codes := codesOf(response.Cards)
+		sort.Strings(codes)
+
+		expectedCodes := []string{"2C", "AC"}
+		if !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected pile cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+	})
+}
+
+func TestReturnPile_ReturnsCardsAndRemovesPile(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithPile(t, service, "hand")
+
+		response, err := service.ReturnPile(deckId, "hand", services.PositionBottom)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if response.Remaining != 4 {
+			t.Errorf("Unexpected remaining count. Expected: %+v, Got: %+v", 4, response.Remaining)
+		}
+
+		expectedCodes := []string{"3C", "4C", "AC", "2C"}
+		if codes := remainingCodes(t, service, deckId); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+
+		_, err = service.ListPile(deckId, "hand")
+		if !errors.Is(err, services.ErrPileNotFound) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrPileNotFound, err)
+		}
+	})
+}
+
+func TestPiles_ErrorIfPileDoesntExist(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithPile(t, service, "hand")
+
+		expectedError := "pile discard not found in deck id: " + deckId
+
+		_, listErr := service.ListPile(deckId, "discard")
+		_, drawErr := service.DrawFromPile(deckId, "discard", 1)
+		_, shuffleErr := service.ShufflePile(deckId, "discard", services.ShuffleOptions{})
+		_, returnErr := service.ReturnPile(deckId, "discard", "")
+
+		for _, err := range []error{listErr, drawErr, shuffleErr, returnErr} {
+			if !errors.Is(err, services.ErrPileNotFound) {
+				t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrPileNotFound, err)
+			} else if err.Error() != expectedError {
+				t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err.Error())
+			}
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestShuffle_PositionsAreUniform(t *testing.T) {
+
+	sources := map[string]services.RandomSource{
+		"crypto": services.CryptoRandom{},
+		"seeded": services.NewSeededRandom(1),
+	}
+
+	codes := [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}, {'4', 'C'}, {'5', 'C'}}
+	const shuffles = 20000
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+
+			service := services.NewDecksServiceWithRandom(createMockDecksConfiguration(), services.NewDecksInMemoryStore(), source)
+
+			// Number of times each card ended up at each position
+			counts := make(map[string][]int)
+			for i := 0; i < shuffles; i++ {
+				created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, Cards: codes})
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				deck, err := service.OpenDeck(created.DeckId)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				for position, card := range deck.Cards {
+					if counts[card.Code] == nil {
+						counts[card.Code] = make([]int, len(codes))
+					}
+					counts[card.Code][position]++
+				}
+			}
+
+			// Pearson's chi-squared statistic of all cards at all positions. If all positions are equally likely,
+			// each of the 25 cells contributes 1 - 1/5 on average, so the statistic is about 20, and hardly ever
+			// exceeds 60. A card landing at one position 10% more often than it should is enough to exceed it.
+			expected := float64(shuffles) / float64(len(codes))
+			chiSquared := 0.0
+			for _, positions := range counts {
+				for _, count := range positions {
+					difference := float64(count) - expected
+					chiSquared += difference * difference / expected
+				}
+			}
+
+			if len(counts) != len(codes) || chiSquared > 60 {
+				t.Errorf("Positions are not uniform, chi-squared: %.2f, counts: %+v", chiSquared, counts)
+			}
+		})
+	}
+}
+
+func TestNewDecksService_SeededRandomSourceIsReproducible(t *testing.T) {
+
+	config := createMockDecksConfiguration()
+	config.Random = configs.RandomConfig{Source: services.RandomSourceSeeded, Seed: 7}
+
+	var orders []string
+	for i := 0; i < 2; i++ {
+		service := services.NewDecksService(config, services.NewDecksInMemoryStore())
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		orders = append(orders, shuffleLine(deck.Cards))
+	}
+
+	if orders[0] != orders[1] {
+		t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", orders[0], orders[1])
+	}
+}
+
+func TestNewDecksService_PanicsOnUnknownRandomSource(t *testing.T) {
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic did not happen")
+		}
+	}()
+
+	config := createMockDecksConfiguration()
+	config.Random = configs.RandomConfig{Source: "dice"}
+	services.NewDecksService(config, services.NewDecksInMemoryStore())
+}
@@ -0,0 +1,232 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Creates an unshuffled deck of four clubs and draws the first two (AC and 2C)
+func createDeckWithTwoDrawn(t *testing.T, service services.DecksServicer) string {
+	created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}, {'3', 'C'}, {'4', 'C'}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return created.DeckId
+}
+
+// Returns the codes of the remaining cards of a deck, in order
+func remainingCodes(t *testing.T, service services.DecksServicer, deckId string) []string {
+	deck, err := service.OpenDeck(deckId)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return codesOf(deck.Cards)
+}
+
+// Returns the codes of cards, in order
+func codesOf(cards []dto.CardDto) []string {
+	codes := make([]string, len(cards))
+	for i, card := range cards {
+		codes[i] = card.Code
+	}
+	return codes
+}
+
+func TestReturnCards_ReturnsToTop(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithTwoDrawn(t, service)
+
+		expectedResponse := &dto.ReturnCardsResponse{
+			DeckId:    deckId,
+			Shuffled:  false,
+			Remaining: 3,
+			Cards: []dto.CardDto{
+				{
+					Suit:  "CLUBS",
+					Value: "2",
+					Code:  "2C",
+				},
+			},
+		}
+
+		response, err := service.ReturnCards(deckId, services.ReturnOptions{Cards: [][2]rune{{'2', 'C'}}, Position: services.PositionTop})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(response, expectedResponse) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedResponse, response)
+		}
+
+		expectedCodes := []string{"2C", "3C", "4C"}
+		if codes := remainingCodes(t, service, deckId); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+	})
+}
+
+func TestReturnCards_ReturnsToBottom(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithTwoDrawn(t, service)
+
+		_, err := service.ReturnCards(deckId, services.ReturnOptions{Cards: [][2]rune{{'A', 'C'}}, Position: services.PositionBottom})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedCodes := []string{"3C", "4C", "AC"}
+		if codes := remainingCodes(t, service, deckId); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+
+		// The card that is still drawn is drawn again only after returning it
+		_, err = service.ReturnCards(deckId, services.ReturnOptions{Cards: [][2]rune{{'A', 'C'}}, Position: services.PositionTop})
+		if !errors.Is(err, services.ErrCardNotDrawn) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrCardNotDrawn, err)
+		}
+	})
+}
+
+func TestReturnCards_ReturnsAllDrawnCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithTwoDrawn(t, service)
+
+		response, err := service.ReturnCards(deckId, services.ReturnOptions{})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if response.Remaining != 4 {
+			t.Errorf("Unexpected remaining count. Expected: %+v, Got: %+v", 4, response.Remaining)
+		}
+
+		expectedCodes := []string{"AC", "2C", "3C", "4C"}
+		if codes := remainingCodes(t, service, deckId); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+	})
+}
+
+func TestReturnCards_ReturnsToRandomPositions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithTwoDrawn(t, service)
+
+		_, err := service.ReturnCards(deckId, services.ReturnOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}}, Position: services.PositionRandom})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		codes := remainingCodes(t, service, deckId)
+		sort.Strings(codes)
+
+		expectedCodes := []string{"2C", "3C", "4C", "AC"}
+		if !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+	})
+}
+
+func TestReturnCards_ErrorIfCardNotDrawn(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithTwoDrawn(t, service)
+
+		// 3C is still in the deck, and KS never belonged to it
+		for _, card := range [][2]rune{{'3', 'C'}, {'K', 'S'}} {
+			_, err := service.ReturnCards(deckId, services.ReturnOptions{Cards: [][2]rune{{'A', 'C'}, card}, Position: services.PositionTop})
+			if !errors.Is(err, services.ErrCardNotDrawn) {
+				t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrCardNotDrawn, err)
+			}
+		}
+
+		// A failed return leaves the deck untouched
+		expectedCodes := []string{"3C", "4C"}
+		if codes := remainingCodes(t, service, deckId); !reflect.DeepEqual(codes, expectedCodes) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", expectedCodes, codes)
+		}
+	})
+}
+
+func TestReturnCards_ErrorIfInvalidPosition(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+		deckId := createDeckWithTwoDrawn(t, service)
+
+		expectedError := "invalid position: middle"
+
+		_, err := service.ReturnCards(deckId, services.ReturnOptions{Position: "middle"})
+		if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		} else if err.Error() != expectedError {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err.Error())
+		}
+	})
+}
+
+func TestShuffleDeck_ShufflesOnlyRemainingCards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 2})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		before := remainingCodes(t, service, created.DeckId)
+
+		response, err := service.ShuffleDeck(created.DeckId, services.ShuffleOptions{})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedResponse := &dto.ShuffleDeckResponse{
+			DeckId:    created.DeckId,
+			Shuffled:  true,
+			Remaining: 50,
+		}
+		if !reflect.DeepEqual(response, expectedResponse) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedResponse, response)
+		}
+
+		after := remainingCodes(t, service, created.DeckId)
+		if reflect.DeepEqual(after, before) {
+			t.Errorf("Remaining cards were not shuffled: %+v", after)
+		}
+
+		sort.Strings(before)
+		sort.Strings(after)
+		if !reflect.DeepEqual(after, before) {
+			t.Errorf("Unexpected remaining cards. Expected: %+v, Got: %+v", before, after)
+		}
+
+		// Drawn cards are still drawn, so they can be returned
+		_, err = service.ReturnCards(created.DeckId, services.ReturnOptions{Cards: [][2]rune{{'A', 'C'}, {'2', 'C'}}, Position: services.PositionTop})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package services_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Run the tests with -update to rewrite golden files. Seeded shuffles must never change, so the golden file
+// of seeded shuffles may only be rewritten to add new cases.
+var update = flag.Bool("update", false, "update golden files")
+
+// Returns the codes of the given cards, followed by the number of the deck they come from in a shoe
+func shuffleLine(cards []dto.CardDto) string {
+	codes := make([]string, len(cards))
+	for i, card := range cards {
+		codes[i] = card.Code
+		if card.Deck != 0 {
+			codes[i] += fmt.Sprintf("/%d", card.Deck)
+		}
+	}
+	return strings.Join(codes, ",")
+}
+
+// Compares the output of a test with a golden file in testdata, or rewrites the golden file with -update
+func compareGolden(t *testing.T, name string, output string) {
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(expected) != output {
+		t.Errorf("Unexpected output. Expected:\n%s\nGot:\n%s", expected, output)
+	}
+}
+
+func TestCreateDeck_SeededShufflesMatchGolden(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		tests := []struct {
+			name    string
+			seed    int64
+			options services.CreateDeckOptions
+		}{
+			{"seed 0", 0, services.CreateDeckOptions{}},
+			{"seed 42", 42, services.CreateDeckOptions{}},
+			{"seed -1", -1, services.CreateDeckOptions{}},
+			{"seed 9223372036854775807", 9223372036854775807, services.CreateDeckOptions{}},
+			{"seed 42, selected cards", 42, services.CreateDeckOptions{Cards: [][2]rune{{'A', 'S'}, {'K', 'H'}, {'2', 'C'}, {'T', 'D'}}}},
+			{"seed 42, shoe of 3 decks", 42, services.CreateDeckOptions{Cards: [][2]rune{{'A', 'S'}, {'K', 'H'}}, DeckCount: 3}},
+		}
+
+		var output strings.Builder
+		for _, test := range tests {
+			seed := test.seed
+			test.options.Shuffle = true
+			test.options.Seed = &seed
+
+			created, err := service.CreateDeck(test.options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			deck, err := service.OpenDeck(created.DeckId)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			fmt.Fprintf(&output, "%s: %s\n", test.name, shuffleLine(deck.Cards))
+		}
+
+		compareGolden(t, "seeded_shuffles", output.String())
+	})
+}
+
+func TestShuffleDeck_ShufflesRemainingCardsLikeNewDeck(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		seed := int64(42)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 48})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		shuffled, err := service.ShuffleDeck(created.DeckId, services.ShuffleOptions{Seed: &seed})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if shuffled.Seed == nil || *shuffled.Seed != seed {
+			t.Errorf("Unexpected seed. Expected: %+v, Got: %+v", seed, shuffled.Seed)
+		}
+
+		// The same seed and cards produce the same order, whether shuffled at creation or later
+		expected, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle: true,
+			Cards:   [][2]rune{{'T', 'S'}, {'J', 'S'}, {'Q', 'S'}, {'K', 'S'}},
+			Seed:    &seed,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedDeck, err := service.OpenDeck(expected.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if shuffleLine(deck.Cards) != shuffleLine(expectedDeck.Cards) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", shuffleLine(expectedDeck.Cards), shuffleLine(deck.Cards))
+		}
+
+		if deck.Seed == nil || *deck.Seed != seed {
+			t.Errorf("Unexpected seed. Expected: %+v, Got: %+v", seed, deck.Seed)
+		}
+	})
+}
+
+func TestShuffleDeck_ClearsSeed(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		seed := int64(7)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, Seed: &seed})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if created.Seed == nil || *created.Seed != seed {
+			t.Errorf("Unexpected seed. Expected: %+v, Got: %+v", seed, created.Seed)
+		}
+
+		shuffled, err := service.ShuffleDeck(created.DeckId, services.ShuffleOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if shuffled.Seed != nil {
+			t.Errorf("Unexpected seed. Expected: %+v, Got: %+v", nil, *shuffled.Seed)
+		}
+	})
+}
+
+func TestShufflePile_IsReproducible(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		seed := int64(1234)
+		var orders []string
+		for i := 0; i < 2; i++ {
+			created, err := service.CreateDeck(services.CreateDeckOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			_, err = service.AddToPile(created.DeckId, "hand", 13, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			pile, err := service.ShufflePile(created.DeckId, "hand", services.ShuffleOptions{Seed: &seed})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			orders = append(orders, shuffleLine(pile.Cards))
+		}
+
+		if orders[0] != orders[1] {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", orders[0], orders[1])
+		}
+	})
+}
+
+func TestCreateDeck_ErrorIfSeedWithoutShuffle(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		seed := int64(1)
+
+		_, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: false, Seed: &seed})
+
+		if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestCreateDeck_BuildsShoeOfFullDecks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		expectedResponse := &dto.CreateDeckResponse{
+			DeckId:    "",
+			Shuffled:  true,
+			Remaining: 8 * 52,
+			Type:      "standard",
+		}
+
+		response, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle:   true,
+			DeckCount: 8,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedResponse.DeckId = response.DeckId
+
+		if !reflect.DeepEqual(response, expectedResponse) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedResponse, response)
+		}
+
+		// Every card appears once in each deck of the shoe
+		deck, err := service.OpenDeck(response.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		seen := make(map[dto.CardDto]bool)
+		for _, card := range deck.Cards {
+			if card.Deck < 1 || card.Deck > 8 {
+				t.Errorf("Unexpected deck number: %+v", card)
+			}
+			if seen[card] {
+				t.Errorf("Card appears twice: %+v", card)
+			}
+			seen[card] = true
+		}
+	})
+}
+
+func TestCreateDeck_BuildsShoeOfPartialDecks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle:   false,
+			Cards:     [][2]rune{{'2', 'C'}, {'A', 'C'}, {'A', 'C'}},
+			DeckCount: 2,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedCards := []dto.CardDto{
+			{Suit: "CLUBS", Value: "ACE", Code: "AC", Deck: 1},
+			{Suit: "CLUBS", Value: "2", Code: "2C", Deck: 1},
+			{Suit: "CLUBS", Value: "ACE", Code: "AC", Deck: 2},
+			{Suit: "CLUBS", Value: "2", Code: "2C", Deck: 2},
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(deck.Cards, expectedCards) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCards, deck.Cards)
+		}
+	})
+}
+
+func TestCreateDeck_ErrorIfInvalidDeckCount(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		for _, deckCount := range []int{-1, services.MaxDeckCount + 1} {
+			_, err := service.CreateDeck(services.CreateDeckOptions{DeckCount: deckCount})
+			if !errors.Is(err, services.ErrInvalidParameter) {
+				t.Errorf("Unexpected error for deck count %d. Expected: %+v, Got: %+v", deckCount, services.ErrInvalidParameter, err)
+			}
+		}
+	})
+}
+
+func TestDrawCards_DrawsMoreThan255Cards(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle:   true,
+			DeckCount: 6,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		response, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 300})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if len(response.Cards) != 300 {
+			t.Errorf("Unexpected number of drawn cards. Expected: %+v, Got: %+v", 300, len(response.Cards))
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if deck.Remaining != 6*52-300 {
+			t.Errorf("Unexpected remaining count. Expected: %+v, Got: %+v", 6*52-300, deck.Remaining)
+		}
+	})
+}
+
+func TestReturnCards_KeepsDeckNumbersOfShoe(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Shuffle:   false,
+			Cards:     [][2]rune{{'A', 'C'}},
+			DeckCount: 3,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 2})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err = service.ReturnCards(created.DeckId, services.ReturnOptions{Cards: [][2]rune{{'A', 'C'}}, Position: services.PositionBottom})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedCards := []dto.CardDto{
+			{Suit: "CLUBS", Value: "ACE", Code: "AC", Deck: 3},
+			{Suit: "CLUBS", Value: "ACE", Code: "AC", Deck: 1},
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(deck.Cards, expectedCards) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCards, deck.Cards)
+		}
+	})
+}
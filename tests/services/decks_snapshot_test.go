@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestSnapshot_MovesDecksBetweenStores(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		journaledId := createJournaledDeck(t, service)
+		twoDrawnId := createDeckWithTwoDrawn(t, service)
+		fair, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true, Fair: true, ClientSeed: "player", Hidden: true, Labels: map[string]string{"table": "7"}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for _, format := range []string{services.SnapshotFormatJsonl, services.SnapshotFormatGzip} {
+			var snapshot bytes.Buffer
+			exported, err := service.ExportDecks(&snapshot, format)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if exported != 3 {
+				t.Errorf("Unexpected number of exported decks. Expected: %+v, Got: %+v", 3, exported)
+			}
+
+			for _, factory := range storeFactories {
+				target := factory.create(t)
+				imported, err := services.ImportSnapshot(target, createMockDecksConfiguration(), bytes.NewReader(snapshot.Bytes()))
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if imported != 3 {
+					t.Errorf("Unexpected number of imported decks. Expected: %+v, Got: %+v", 3, imported)
+				}
+
+				targetService := services.NewDecksService(createMockDecksConfiguration(), target)
+				for _, deckId := range []string{journaledId, twoDrawnId} {
+					expected := journaledDeckOf(t, service, deckId)
+					if got := journaledDeckOf(t, targetService, deckId); !reflect.DeepEqual(got, expected) {
+						t.Errorf("Unexpected deck in %s imported from %s. Expected: %+v, Got: %+v", factory.name, format, expected, got)
+					}
+				}
+
+				// Hidden decks and their provably fair shuffle are kept
+				expected, err := store.Get(uuid.MustParse(fair.DeckId))
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				deck, err := target.Get(uuid.MustParse(fair.DeckId))
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !deck.Hidden || !reflect.DeepEqual(deck.Fairness, expected.Fairness) || !reflect.DeepEqual(deck.Labels, expected.Labels) ||
+					!deck.CreatedAt.Equal(expected.CreatedAt) {
+					t.Errorf("Unexpected deck in %s imported from %s. Expected: %+v, Got: %+v", factory.name, format, expected, deck)
+				}
+			}
+		}
+	})
+}
+
+func TestSnapshot_ErrorIfInvalid(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		tests := []string{
+			`{"deck_id":"not-a-uuid","cards":"AC","remaining":1,"piles":"{}","pile_decks":"{}"}`,
+			`{"deck_id":"5b25d675-b285-4713-b976-9571a404f88a","cards":"AC","remaining":2,"piles":"{}","pile_decks":"{}"}`,
+			`{"deck_id":"5b25d675-b285-4713-b976-9571a404f88a","cards":"AC","remaining":1,"piles":"{}","pile_decks":"{}","color":"red"}`,
+			`{"deck_id":"5b25d675-b285-4713-b976-9571a404f88a","type":"tarot","cards":"AC","remaining":1,"piles":"{}","pile_decks":"{}"}`,
+			`{"deck_id":"5b25d675-b285-4713-b976-9571a404f88a","type":"standard","cards":"ACXR","remaining":2,"piles":"{}","pile_decks":"{}"}`,
+			`{"deck_id":`,
+		}
+
+		for _, snapshot := range tests {
+			_, err := services.ImportSnapshot(store, createMockDecksConfiguration(), strings.NewReader(snapshot))
+			if !errors.Is(err, services.ErrInvalidParameter) {
+				t.Errorf("Unexpected error importing %s. Expected: %+v, Got: %+v", snapshot, services.ErrInvalidParameter, err)
+			}
+		}
+
+		_, err := services.ExportSnapshot(store, &bytes.Buffer{}, "zip")
+		if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+	})
+}
+
+func TestSnapshot_ImportsNothingIfAnyDeckInvalid(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		valid := `{"deck_id":"5b25d675-b285-4713-b976-9571a404f88a","type":"standard","cards":"AC","remaining":1,"piles":"{}","pile_decks":"{}"}`
+		invalid := `{"deck_id":"0c3f6f4e-8a3c-4a57-9d0e-0d5c1b0e6a11","type":"standard","cards":"AC","remaining":2,"piles":"{}","pile_decks":"{}"}`
+
+		imported, err := services.ImportSnapshot(store, createMockDecksConfiguration(), strings.NewReader(valid+"\n"+invalid+"\n"))
+		if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+		if imported != 0 {
+			t.Errorf("Unexpected number of imported decks. Expected: %+v, Got: %+v", 0, imported)
+		}
+
+		if _, err := store.Get(uuid.MustParse("5b25d675-b285-4713-b976-9571a404f88a")); !errors.Is(err, services.ErrNotFound) {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", services.ErrNotFound, err)
+		}
+	})
+}
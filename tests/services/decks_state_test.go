@@ -0,0 +1,56 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestChanges_ReturnStateOfDeckIfRequested(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{State: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if created.Deck == nil || created.Deck.DeckId != created.DeckId || created.Deck.Remaining != 52 {
+			t.Errorf("Unexpected state of created deck: %+v", created.Deck)
+		}
+
+		drawn, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2, State: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if drawn.Deck == nil || drawn.Deck.Remaining != 50 || drawn.Deck.Drawn != 2 {
+			t.Errorf("Unexpected state of deck after drawing: %+v", drawn.Deck)
+		}
+
+		returned, err := service.ReturnCards(created.DeckId, services.ReturnOptions{State: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if returned.Deck == nil || returned.Deck.Remaining != 52 || returned.Deck.Drawn != 0 {
+			t.Errorf("Unexpected state of deck after returning: %+v", returned.Deck)
+		}
+
+		seed := int64(7)
+		shuffled, err := service.ShuffleDeck(created.DeckId, services.ShuffleOptions{Seed: &seed, State: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if shuffled.Deck == nil || !shuffled.Deck.Shuffled || shuffled.Deck.Seed == nil || *shuffled.Deck.Seed != seed {
+			t.Errorf("Unexpected state of deck after shuffling: %+v", shuffled.Deck)
+		}
+
+		// Without the option, the state is left out
+		drawn, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 1})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if drawn.Deck != nil {
+			t.Errorf("Unexpected state of deck. Expected: %+v, Got: %+v", nil, drawn.Deck)
+		}
+	})
+}
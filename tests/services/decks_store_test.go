@@ -0,0 +1,412 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// Number of goroutines hammering one deck in concurrency tests
+const concurrentWorkers = 100
+
+func TestUpdate_AppliesChange(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		id, err := store.Create(&models.Deck{
+			Cards:     cardsOf("AC", "2C"),
+			Remaining: 2,
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		updated, err := store.Update(id, func(deck *models.Deck) error {
+			deck.Remaining = 1
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		deck, err := store.Get(id)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if updated.Remaining != 1 || deck.Remaining != 1 {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v and %+v", 1, updated.Remaining, deck.Remaining)
+		}
+	})
+}
+
+func TestUpdate_DiscardsChangeOnError(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		id, err := store.Create(&models.Deck{
+			Cards:     cardsOf("AC", "2C"),
+			Remaining: 2,
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedError := errors.New("update failed")
+
+		_, err = store.Update(id, func(deck *models.Deck) error {
+			deck.Remaining = 0
+			deck.Cards[0] = models.Card{Code: [2]rune{'K', 'S'}}
+			return expectedError
+		})
+		if err != expectedError {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err)
+		}
+
+		deck, err := store.Get(id)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if deck.Remaining != 2 || deck.Cards[0].Code != [2]rune{'A', 'C'} {
+			t.Errorf("Unexpected response. Deck was modified by a failed update: %+v", deck)
+		}
+	})
+}
+
+func TestUpdate_ErrorIfDeckDoesntExist(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		_, err := store.Update([16]byte{}, func(deck *models.Deck) error {
+			return nil
+		})
+		if err == nil {
+			t.Errorf("Expected error was not returned")
+		}
+	})
+}
+
+func TestUpdate_ConcurrentUpdatesAreNotLost(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		id, err := store.Create(&models.Deck{
+			Cards:     make([]models.Card, 255),
+			Remaining: 255,
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := store.Update(id, func(deck *models.Deck) error {
+					deck.Remaining--
+					return nil
+				})
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		deck, err := store.Get(id)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if deck.Remaining != 255-concurrentWorkers {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", 255-concurrentWorkers, deck.Remaining)
+		}
+	})
+}
+
+func TestDrawCards_ConcurrentDrawsNeverDealSameCard(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		var mu sync.Mutex
+		dealt := make(map[string]int)
+		failures := 0
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 1})
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures++
+					return
+				}
+				for _, card := range response.Cards {
+					dealt[card.Code]++
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(dealt) != 52 {
+			t.Errorf("Unexpected number of distinct cards dealt. Expected: %+v, Got: %+v", 52, len(dealt))
+		}
+
+		for code, count := range dealt {
+			if count != 1 {
+				t.Errorf("Card %s was dealt %d times", code, count)
+			}
+		}
+
+		if failures != concurrentWorkers-52 {
+			t.Errorf("Unexpected number of failed draws. Expected: %+v, Got: %+v", concurrentWorkers-52, failures)
+		}
+	})
+}
+
+func TestCreate_PreservesCardOrder(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		expectedCards := []models.Card{
+			{Code: [2]rune{'K', 'S'}, Deck: 2},
+			{Code: [2]rune{'A', 'C'}, Deck: 1},
+			{Code: [2]rune{'Ä', '♠'}, Deck: 1},
+			{Code: [2]rune{'2', 'C'}, Deck: 2},
+			{Code: [2]rune{'A', 'C'}, Deck: 2},
+		}
+
+		id, err := store.Create(&models.Deck{
+			Cards:     copyOf(expectedCards),
+			Shuffled:  true,
+			Remaining: 4,
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedDeck := &models.Deck{
+			DeckId:    id,
+			Cards:     expectedCards,
+			Shuffled:  true,
+			Remaining: 4,
+		}
+
+		deck, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Timestamps are set by the repository
+		expectedDeck.CreatedAt = deck.CreatedAt
+		expectedDeck.LastAccessed = deck.LastAccessed
+
+		if !reflect.DeepEqual(deck, expectedDeck) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedDeck, deck)
+		}
+	})
+}
+
+func TestPut_OverwritesDeck(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		id, err := store.Create(&models.Deck{
+			Cards:     cardsOf("AC", "2C"),
+			Remaining: 2,
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedDeck := &models.Deck{
+			DeckId:    id,
+			Cards:     cardsOf("2C", "AC"),
+			Shuffled:  true,
+			Remaining: 1,
+		}
+
+		err = store.Put(copyDeck(expectedDeck))
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		deck, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Timestamps are set by the repository
+		expectedDeck.CreatedAt = deck.CreatedAt
+		expectedDeck.LastAccessed = deck.LastAccessed
+
+		if !reflect.DeepEqual(deck, expectedDeck) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedDeck, deck)
+		}
+	})
+}
+
+func TestUpdate_PreservesPiles(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		id, err := store.Create(&models.Deck{
+			Cards:     cardsOf("AC", "2C", "3C"),
+			Remaining: 3,
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedPiles := map[string][]models.Card{
+			"hand":    cardsOf("AC", "2C"),
+			"discard": {{Code: [2]rune{'3', 'C'}, Deck: 3}},
+			"empty":   {},
+		}
+
+		_, err = store.Update(id, func(deck *models.Deck) error {
+			deck.Cards = nil
+			deck.Remaining = 0
+			deck.Piles = map[string][]models.Card{
+				"hand":    copyOf(expectedPiles["hand"]),
+				"discard": copyOf(expectedPiles["discard"]),
+				"empty":   {},
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		deck, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(deck.Piles, expectedPiles) {
+			t.Errorf("Unexpected piles. Expected: %+v, Got: %+v", expectedPiles, deck.Piles)
+		}
+	})
+}
+
+func TestDelete_RemovesDeck(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		id, err := store.Create(&models.Deck{
+			Cards:     cardsOf("AC"),
+			Remaining: 1,
+		})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		err = store.Delete(id)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		expectedError := fmt.Sprintf("data not found for id: %s", id)
+
+		_, err = store.Get(id)
+		if err == nil {
+			t.Errorf("Expected error was not returned: %s", expectedError)
+		} else if expectedError != err.Error() {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err.Error())
+		}
+
+		err = store.Delete(id)
+		if err == nil {
+			t.Errorf("Expected error was not returned: %s", expectedError)
+		}
+	})
+}
+
+func TestGet_UpdatesAccessTime(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		created := &models.Deck{
+			Cards:     cardsOf("AC"),
+			Remaining: 1,
+		}
+
+		id, err := store.Create(created)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if created.CreatedAt.IsZero() || !created.LastAccessed.Equal(created.CreatedAt) {
+			t.Errorf("Unexpected timestamps of created deck: %+v", created)
+		}
+
+		deck, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if !deck.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Unexpected creation time. Expected: %+v, Got: %+v", created.CreatedAt, deck.CreatedAt)
+		}
+
+		if deck.LastAccessed.Before(created.LastAccessed) {
+			t.Errorf("Access time was not updated. Created: %+v, Got: %+v", created.LastAccessed, deck.LastAccessed)
+		}
+	})
+}
+
+func TestSqliteStore_DecksSurviveReopening(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "decks.db")
+
+	store := newSqliteStore(t, path)
+	service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+	created, err := service.CreateDeck(services.CreateDeckOptions{Shuffle: true})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = service.DrawCards(created.DeckId, services.DrawOptions{Count: 2})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expectedResponse, err := service.OpenDeck(created.DeckId)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	store.Close()
+
+	reopened := services.NewDecksService(createMockDecksConfiguration(), newSqliteStore(t, path))
+
+	response, err := reopened.OpenDeck(created.DeckId)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(response, expectedResponse) {
+		t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedResponse, response)
+	}
+}
+
+// Copies a slice of cards, so tests can keep an expected value that the repository cannot modify
+func copyOf(cards []models.Card) []models.Card {
+	return append([]models.Card{}, cards...)
+}
+
+// Copies a deck with its cards
+func copyDeck(deck *models.Deck) *models.Deck {
+	result := *deck
+	result.Cards = copyOf(deck.Cards)
+	return &result
+}
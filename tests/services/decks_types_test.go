@@ -0,0 +1,184 @@
+package services_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models/configs"
+	"github.com/rnkjnk/decks-api/internal/models/dto"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// A standard deck, and a piquet deck of 32 cards
+func createMockDecksConfigurationWithTypes() configs.DecksConfig {
+	decksConfig := createMockDecksConfiguration()
+	decksConfig.Types = map[string]configs.DeckTypeConfig{
+		"piquet": {
+			Suits:  []string{"CLUBS", "DIAMONDS", "HEARTS", "SPADES"},
+			Values: []string{"7", "8", "9", "TEN", "JACK", "QUEEN", "KING", "ACE"},
+		},
+		"jokers": {
+			Extras: []configs.ExtraCardConfig{
+				{Code: "XR", Value: "JOKER", Suit: "RED"},
+				{Code: "XB", Value: "JOKER", Suit: "BLACK"},
+			},
+		},
+	}
+	return decksConfig
+}
+
+func TestCreateDeck_UsesDeckType(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfigurationWithTypes(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Type: "piquet"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedResponse := &dto.CreateDeckResponse{
+			DeckId:    created.DeckId,
+			Shuffled:  false,
+			Remaining: 32,
+			Type:      "piquet",
+		}
+
+		if !reflect.DeepEqual(created, expectedResponse) {
+			t.Errorf("Unexpected response. Expected: %+v, Got: %+v", expectedResponse, created)
+		}
+
+		deck, err := service.OpenDeck(created.DeckId)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if deck.Type != "piquet" {
+			t.Errorf("Unexpected type. Expected: %+v, Got: %+v", "piquet", deck.Type)
+		}
+
+		expectedCards := []dto.CardDto{
+			{Suit: "CLUBS", Value: "7", Code: "7C"},
+			{Suit: "CLUBS", Value: "8", Code: "8C"},
+		}
+
+		if !reflect.DeepEqual(deck.Cards[:2], expectedCards) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCards, deck.Cards[:2])
+		}
+	})
+}
+
+func TestCreateDeck_SelectsCardsOfDeckType(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfigurationWithTypes(), store)
+
+		// The two of clubs is not part of a piquet deck
+		created, err := service.CreateDeck(services.CreateDeckOptions{
+			Type:  "piquet",
+			Cards: [][2]rune{{'2', 'C'}, {'7', 'C'}},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		response, err := service.DrawCards(created.DeckId, services.DrawOptions{Count: 1})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expectedCards := []dto.CardDto{
+			{Suit: "CLUBS", Value: "7", Code: "7C"},
+		}
+
+		if !reflect.DeepEqual(response.Cards, expectedCards) {
+			t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCards, response.Cards)
+		}
+	})
+}
+
+func TestCreateDeck_ErrorIfDeckTypeUnknown(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfigurationWithTypes(), store)
+
+		_, err := service.CreateDeck(services.CreateDeckOptions{Type: "tarot"})
+
+		expectedError := "unknown deck type: tarot"
+
+		if err == nil {
+			t.Errorf("Expected error was not returned: %s", expectedError)
+		} else if expectedError != err.Error() {
+			t.Errorf("Unexpected error. Expected: %+v, Got: %+v", expectedError, err.Error())
+		} else if !errors.Is(err, services.ErrInvalidParameter) {
+			t.Errorf("Unexpected error kind. Expected: %+v, Got: %+v", services.ErrInvalidParameter, err)
+		}
+	})
+}
+
+func TestListDeckTypes_ListsAllTypes(t *testing.T) {
+
+	config := createMockDecksConfigurationWithTypes()
+	config.DefaultType = "piquet"
+	service := services.NewDecksService(config, services.NewDecksInMemoryStore())
+
+	response, err := service.ListDeckTypes()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	names := make([]string, len(response.Types))
+	for i, deckType := range response.Types {
+		names[i] = deckType.Name
+		if deckType.Size != len(deckType.Cards) {
+			t.Errorf("Unexpected size of type %s. Expected: %+v, Got: %+v", deckType.Name, len(deckType.Cards), deckType.Size)
+		}
+		if deckType.Default != (deckType.Name == "piquet") {
+			t.Errorf("Unexpected default flag of type %s: %+v", deckType.Name, deckType.Default)
+		}
+	}
+
+	expectedNames := []string{"jokers", "piquet", "standard"}
+
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Unexpected types. Expected: %+v, Got: %+v", expectedNames, names)
+	}
+
+	expectedCards := []dto.CardDto{
+		{Suit: "RED", Value: "JOKER", Code: "XR"},
+		{Suit: "BLACK", Value: "JOKER", Code: "XB"},
+	}
+
+	if !reflect.DeepEqual(response.Types[0].Cards, expectedCards) {
+		t.Errorf("Unexpected cards. Expected: %+v, Got: %+v", expectedCards, response.Types[0].Cards)
+	}
+}
+
+func TestNewDecksService_PanicsOnBadDeckTypes(t *testing.T) {
+
+	tests := map[string]func(*configs.DecksConfig){
+		"unknown default type": func(config *configs.DecksConfig) {
+			config.DefaultType = "tarot"
+		},
+		"duplicate standard type": func(config *configs.DecksConfig) {
+			config.Types = map[string]configs.DeckTypeConfig{
+				services.StandardDeckType: {Suits: []string{"CLUBS"}, Values: []string{"ACE"}},
+			}
+		},
+	}
+
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected panic did not happen")
+				}
+			}()
+
+			config := createMockDecksConfiguration()
+			modify(&config)
+			services.NewDecksService(config, services.NewDecksInMemoryStore())
+		})
+	}
+}
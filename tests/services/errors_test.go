@@ -0,0 +1,51 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+func TestErrors_KindsOfServiceErrors(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store services.DecksStorer) {
+
+		service := services.NewDecksService(createMockDecksConfiguration(), store)
+
+		created, err := service.CreateDeck(services.CreateDeckOptions{Cards: [][2]rune{{'A', 'C'}}})
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		_, notFound := service.OpenDeck("5b25d675-b285-4713-b976-9571a404f88a")
+		_, invalidId := service.DrawCards("this is not a valid uuid", services.DrawOptions{Count: 1})
+		_, insufficientCards := service.DrawCards(created.DeckId, services.DrawOptions{Count: 2})
+		_, _ = service.DrawCards(created.DeckId, services.DrawOptions{Count: 1})
+		_, emptyDeck := service.OpenDeck(created.DeckId)
+
+		tests := []struct {
+			name          string
+			err           error
+			expectedKind  error
+			expectedField string
+		}{
+			{"not found", notFound, services.ErrNotFound, "id"},
+			{"invalid id", invalidId, services.ErrInvalidId, "id"},
+			{"insufficient cards", insufficientCards, services.ErrInsufficientCards, "draw"},
+			{"empty deck", emptyDeck, services.ErrEmptyDeck, "id"},
+		}
+
+		for _, test := range tests {
+			if !errors.Is(test.err, test.expectedKind) {
+				t.Errorf("Unexpected error kind for %s. Expected: %+v, Got: %+v", test.name, test.expectedKind, test.err)
+			}
+
+			var serviceError *services.Error
+			if !errors.As(test.err, &serviceError) {
+				t.Errorf("Unexpected error type for %s: %T", test.name, test.err)
+			} else if serviceError.Field != test.expectedField {
+				t.Errorf("Unexpected field for %s. Expected: %+v, Got: %+v", test.name, test.expectedField, serviceError.Field)
+			}
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package services_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rnkjnk/decks-api/internal/models"
+	"github.com/rnkjnk/decks-api/internal/services"
+)
+
+// A named constructor of a fresh, empty repository
+type storeFactory struct {
+	name   string
+	create func(t *testing.T) services.DecksStorer
+}
+
+// All repository implementations. Every test run through forEachStore runs against each of them,
+// which makes the service and repository tests a conformance suite for DecksStorer.
+var storeFactories = []storeFactory{
+	{
+		name: "memory",
+		create: func(t *testing.T) services.DecksStorer {
+			return services.NewDecksInMemoryStore()
+		},
+	},
+	{
+		name: "sqlite",
+		create: func(t *testing.T) services.DecksStorer {
+			return newSqliteStore(t, filepath.Join(t.TempDir(), "decks.db"))
+		},
+	},
+	{
+		name: "journal",
+		create: func(t *testing.T) services.DecksStorer {
+			return newJournalStore(t, t.TempDir())
+		},
+	},
+}
+
+// Runs a test once for every repository implementation, each time with a fresh repository
+func forEachStore(t *testing.T, test func(t *testing.T, store services.DecksStorer)) {
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			test(t, factory.create(t))
+		})
+	}
+}
+
+// Opens an SQLite repository that is closed when the test ends
+func newSqliteStore(t *testing.T, path string) *services.DecksSqliteStore {
+	store, err := services.NewDecksSqliteStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+	})
+	return store
+}
+
+// Opens a journal repository that is closed when the test ends
+func newJournalStore(t *testing.T, dir string) *services.DecksJournalStore {
+	store, err := services.NewDecksJournalStore(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+	})
+	return store
+}
+
+// Creates cards from their codes
+func cardsOf(codes ...string) []models.Card {
+	cards := make([]models.Card, len(codes))
+	for i, code := range codes {
+		runes := []rune(code)
+		cards[i].Code = [2]rune{runes[0], runes[1]}
+	}
+	return cards
+}
+
+// Returns the codes of cards, in order
+func codesOfCards(cards []models.Card) [][2]rune {
+	codes := make([][2]rune, len(cards))
+	for i, card := range cards {
+		codes[i] = card.Code
+	}
+	return codes
+}